@@ -6,6 +6,7 @@ import (
 	"github.com/gen2brain/beeep"
 	"github.com/valentinclaes/claude-hooks/internal/config"
 	"github.com/valentinclaes/claude-hooks/internal/focus"
+	"github.com/valentinclaes/claude-hooks/internal/hookdata"
 )
 
 func main() {
@@ -23,9 +24,12 @@ func main() {
 	title := "Claude"
 	message := "Task completed!"
 
+	fromStdin := false
 	args := os.Args[1:]
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
+		case "--stdin":
+			fromStdin = true
 		case "--title":
 			if i+1 < len(args) {
 				title = args[i+1]
@@ -39,5 +43,19 @@ func main() {
 		}
 	}
 
+	// Prefer the text of the notification itself; --title and --message
+	// remain the fallback for hooks that carry none (e.g. Stop)
+	if fromStdin {
+		var input hookdata.NotificationInput
+		if err := hookdata.ReadStdin(&input); err == nil {
+			if input.Title != "" {
+				title = input.Title
+			}
+			if input.Message != "" {
+				message = input.Message
+			}
+		}
+	}
+
 	beeep.Alert(title, message, "")
 }
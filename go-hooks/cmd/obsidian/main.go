@@ -1,25 +1,30 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"math"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/valentinclaes/claude-hooks/internal/atomicfile"
+	"github.com/valentinclaes/claude-hooks/internal/backfill"
+	"github.com/valentinclaes/claude-hooks/internal/config"
+	"github.com/valentinclaes/claude-hooks/internal/filelock"
 	"github.com/valentinclaes/claude-hooks/internal/gitsync"
 	"github.com/valentinclaes/claude-hooks/internal/hookdata"
 	"github.com/valentinclaes/claude-hooks/internal/obsidian"
+	"github.com/valentinclaes/claude-hooks/internal/redact"
 	"github.com/valentinclaes/claude-hooks/internal/session"
+	"github.com/valentinclaes/claude-hooks/internal/transcript"
 )
 
-var startTimeRe = regexp.MustCompile(`(?m)^start_time:\s*(\d{2}:\d{2})`)
 var durationLineRe = regexp.MustCompile(`(?m)^duration:.*$`)
-var startTimeLineRe = regexp.MustCompile(`(?m)^(start_time:\s*.*)$`)
+var startTimeLineRe = regexp.MustCompile(`(?m)^(start_time:\s*.*(?:\nstarted_at:.*)?)$`)
 
 func main() {
 	defer func() {
@@ -27,15 +32,35 @@ func main() {
 	}()
 
 	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, "usage: claude-obsidian <log-prompt|log-response>")
+		fmt.Fprintln(os.Stderr, "usage: claude-obsidian <session-start|log-prompt|log-response|log-tool|log-compact|log-subagent|log-notification|session-end|backfill|reindex>")
 		os.Exit(0)
 	}
 
+	if dir := config.HooksDir(); dir != "" {
+		obsidian.LoadTemplates(filepath.Join(dir, "templates"))
+	}
+
 	switch os.Args[1] {
 	case "log-prompt":
 		runLogPrompt()
 	case "log-response":
 		runLogResponse()
+	case "log-tool":
+		runLogTool()
+	case "log-compact":
+		runLogCompact()
+	case "log-subagent":
+		runLogSubagent()
+	case "log-notification":
+		runLogNotification()
+	case "session-start":
+		runSessionStart()
+	case "session-end":
+		runSessionEnd()
+	case "backfill":
+		runBackfill(os.Args[2:])
+	case "reindex":
+		runReindex(os.Args[2:])
 	default:
 		fmt.Fprintf(os.Stderr, "unknown command: %s\n", os.Args[1])
 	}
@@ -54,18 +79,16 @@ func runLogPrompt() {
 	if prompt == "" {
 		return
 	}
-	prompt = obsidian.Truncate(prompt, 5000)
+	cfg := config.Load()
+	prompt = redact.New(cfg.Redact).Redact(prompt)
 
 	vaultDir := obsidian.VaultDir()
 	if vaultDir == "" {
 		return
 	}
 
-	claudeProjects := filepath.Join(os.Getenv("USERPROFILE"), ".claude", "projects")
 	now := time.Now()
-	date := now.Format("2006-01-02")
 	timeStr := now.Format("15:04:05")
-	project := obsidian.SanitizeProject(filepath.Base(input.Cwd))
 
 	// Ensure vault dir exists
 	os.MkdirAll(vaultDir, 0755)
@@ -73,50 +96,311 @@ func runLogPrompt() {
 	// Clean up stale session files
 	session.CleanupStale()
 
-	// Check for existing session mapping
-	sd, _ := session.Read(input.SessionID)
+	// Serialize with other hooks of this session
+	unlock, _ := session.Lock(input.SessionID)
+	defer unlock()
 
-	var filePath string
-	var promptNum int
+	// Check for existing session mapping. State that exists but cannot be
+	// read must not be replaced by a new note, orphaning the old one.
+	sd, err := session.Read(input.SessionID)
+	if err != nil {
+		return
+	}
 
 	if sd != nil {
-		filePath = sd.FilePath
-		promptNum = sd.PromptNum + 1
-		session.Write(input.SessionID, filePath, promptNum)
-	} else {
-		// New session
-		promptNum = 1
-		projectDir := filepath.Join(vaultDir, project)
-		os.MkdirAll(projectDir, 0755)
-
-		timeShort := now.Format("1504")
-		fileName := fmt.Sprintf("%s_%s.md", date, timeShort)
-		filePath = filepath.Join(projectDir, fileName)
-
-		// Handle collision
-		counter := 2
-		for {
-			if _, err := os.Stat(filePath); os.IsNotExist(err) {
-				break
+		sd.PromptNum++
+		sd.GitBranch = gitsync.Branch(input.Cwd)
+		// A note created by session-start gets its title from the first prompt
+		if sd.Title == "" {
+			if title := obsidian.SessionTitle(prompt); title != "" {
+				sd.Title = title
+				updateTitle(sd.FilePath, title)
+				if cfg.SlugFilenames {
+					renameNote(sd, title)
+				}
 			}
-			fileName = fmt.Sprintf("%s_%s_%d.md", date, timeShort, counter)
-			filePath = filepath.Join(projectDir, fileName)
-			counter++
 		}
+	} else {
+		// New session, without a session-start hook
+		sd = startSession(input.SessionID, input.Cwd, "", obsidian.SessionTitle(prompt), now, cfg, vaultDir)
+		sd.PromptNum = 1
+	}
+	sd.AddEvent(session.EventPrompt, now)
+	session.Write(input.SessionID, sd)
 
-		session.Write(input.SessionID, filePath, 1)
+	// Append prompt entry
+	limiter := obsidian.Limiter{Limits: cfg.Limits, VaultDir: vaultDir, NotePath: sd.FilePath}
+	info := sessionInfo(input.SessionID, sd)
+	info.Cwd = input.Cwd
+	entry := obsidian.FormatPromptEntry(obsidian.EntryInfo{
+		SessionInfo: info,
+		Time:        timeStr,
+		Text:        limiter.Fit(prompt, cfg.Limits.Prompt, obsidian.Truncate),
+	})
+	f, err := os.OpenFile(sd.FilePath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(entry)
+}
 
-		// Check for parent session
-		resumedFrom := obsidian.FindParentSession(input.SessionID, claudeProjects, vaultDir)
+// startSession creates the note of a new session and returns its state,
+// not yet written. source is the SessionStart source, or "" when the
+// session is created by its first prompt.
+func startSession(sessionID, cwd, source, title string, now time.Time, cfg config.Config, vaultDir string) *session.SessionData {
+	project := obsidian.SanitizeProject(filepath.Base(cwd))
+	projectDir := filepath.Join(vaultDir, project)
+	os.MkdirAll(projectDir, 0755)
+
+	sd := &session.SessionData{
+		FilePath:  obsidian.NewNotePath(projectDir, now, title, cfg.SlugFilenames),
+		StartTime: now,
+		Project:   project,
+		Title:     title,
+		Cwd:       cwd,
+		GitBranch: gitsync.Branch(cwd),
+		Source:    source,
+	}
 
-		startTime := now.Format("15:04")
-		frontmatter := obsidian.BuildFrontmatter(date, input.SessionID, project, startTime, resumedFrom)
-		os.WriteFile(filePath, []byte(frontmatter), 0644)
+	// Check for parent session
+	info := sessionInfo(sessionID, sd)
+	info.ResumedFrom = obsidian.FindParentSession(sessionID, filepath.Join(config.ClaudeDir(), "projects"), vaultDir)
+	if source != "" {
+		info.Status = obsidian.StatusRunning
 	}
+	atomicfile.WriteFile(sd.FilePath, []byte(obsidian.BuildFrontmatter(info)), 0644)
+	return sd
+}
 
-	// Append prompt entry
-	entry := obsidian.FormatPromptEntry(promptNum, timeStr, input.Cwd, prompt)
-	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
+// renameNote moves a note created before its title was known to a file name
+// with the slug of title.
+func renameNote(sd *session.SessionData, title string) {
+	path := obsidian.NewNotePath(filepath.Dir(sd.FilePath), sd.StartTime, title, true)
+	if os.Rename(sd.FilePath, path) == nil {
+		sd.FilePath = path
+	}
+}
+
+func runSessionStart() {
+	var input hookdata.SessionStartInput
+	if err := hookdata.ReadStdin(&input); err != nil {
+		return
+	}
+	vaultDir := obsidian.VaultDir()
+	if vaultDir == "" || input.SessionID == "" {
+		return
+	}
+	os.MkdirAll(vaultDir, 0755)
+	session.CleanupStale()
+
+	unlock, _ := session.Lock(input.SessionID)
+	defer unlock()
+
+	sd, err := session.Read(input.SessionID)
+	if err != nil {
+		return // unreadable state: keep its note rather than start another
+	}
+	switch {
+	case sd != nil:
+		// Resumed or compacted while still open: keep logging to the same note
+		if input.Source == "compact" {
+			logCompactSummary(input.TranscriptPath, input.SessionID, sd)
+		}
+	case input.Source == "resume":
+		// Reopen the note of a session that already ended, if there is one
+		if note, ok := obsidian.SessionNotes(vaultDir)[input.SessionID]; ok {
+			sd = reopenSession(note, input.Source, input.Cwd, input.TranscriptPath)
+		}
+	}
+	if sd == nil {
+		sd = startSession(input.SessionID, input.Cwd, input.Source, "", time.Now(), config.Load(), vaultDir)
+	}
+	session.Write(input.SessionID, sd)
+}
+
+// reopenSession rebuilds the state of an ended session from its note and
+// marks the note as running again. The note already holds everything up to
+// the end of the transcript, so logging resumes from there.
+func reopenSession(notePath, source, cwd, transcriptPath string) *session.SessionData {
+	data, err := os.ReadFile(notePath)
+	if err != nil {
+		return nil
+	}
+	content := string(data)
+	start, ok := obsidian.NoteStart(content)
+	if !ok {
+		return nil
+	}
+	usage := obsidian.ReadUsage(content)
+	prompts, _ := strconv.Atoi(obsidian.FrontmatterField(content, "prompts"))
+	sd := &session.SessionData{
+		FilePath:  notePath,
+		PromptNum: prompts,
+		StartTime: start.Local(),
+		Project:   obsidian.FrontmatterField(content, "project"),
+		Title:     obsidian.NoteTitle(content),
+		Cwd:       cwd,
+		GitBranch: gitsync.Branch(cwd),
+		Source:    source,
+		Model:     usage.Model,
+		Tokens: session.Tokens{
+			Input:         usage.InputTokens,
+			Output:        usage.OutputTokens,
+			CacheRead:     usage.CacheReadTokens,
+			CacheCreation: usage.CacheCreationTokens,
+			CostUSD:       usage.CostUSD,
+		},
+	}
+	if info, err := os.Stat(transcriptPath); err == nil {
+		sd.Offset = info.Size()
+	}
+	content = obsidian.SetFrontmatterField(content, "source", source)
+	content = obsidian.SetFrontmatterField(content, "status", obsidian.StatusRunning)
+	for _, key := range []string{"end_time", "ended_at", "end_reason"} {
+		content = obsidian.RemoveFrontmatterField(content, key)
+	}
+	atomicfile.WriteFile(notePath, []byte(content), 0644)
+	return sd
+}
+
+func runSessionEnd() {
+	var input hookdata.SessionEndInput
+	if err := hookdata.ReadStdin(&input); err != nil {
+		return
+	}
+	unlock, _ := session.Lock(input.SessionID)
+	defer unlock()
+
+	sd, _ := session.Read(input.SessionID)
+	if sd == nil {
+		return
+	}
+	// The session is over either way; its note no longer needs the state
+	session.Remove(input.SessionID)
+
+	filePath := sd.FilePath
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return
+	}
+	if sd.PromptNum == 0 && !strings.Contains(string(content), "[!user]") {
+		// Opened and closed without a prompt: nothing worth keeping
+		os.Remove(filePath)
+		return
+	}
+
+	now := time.Now()
+	cfg := config.Load()
+	updateDuration(filePath, now, activeDuration(sd, cfg))
+	updateEnd(filePath, now, input.Reason, sd.PromptNum)
+	unlock()
+
+	rebuildIndexes(obsidian.VaultDir(), sd, now, cfg)
+}
+
+func runLogCompact() {
+	var input hookdata.CompactInput
+	if err := hookdata.ReadStdin(&input); err != nil {
+		return
+	}
+
+	unlock, _ := session.Lock(input.SessionID)
+	defer unlock()
+
+	// Compactions are only logged into an existing session note
+	sd, _ := session.Read(input.SessionID)
+	if sd == nil {
+		return
+	}
+	content, err := os.ReadFile(sd.FilePath)
+	if err != nil {
+		return
+	}
+
+	cfg := config.Load()
+	limiter := obsidian.Limiter{Limits: cfg.Limits, VaultDir: obsidian.VaultDir(), NotePath: sd.FilePath}
+	instructions := redact.New(cfg.Redact).Redact(strings.TrimSpace(input.CustomInstructions))
+	trigger := input.Trigger
+	if trigger == "" {
+		trigger = "auto"
+	}
+	entry := obsidian.FormatCompactEntry(obsidian.CompactInfo{
+		EntryInfo: obsidian.EntryInfo{
+			SessionInfo: sessionInfo(input.SessionID, sd),
+			Time:        time.Now().Format("15:04:05"),
+			Text:        limiter.Fit(instructions, cfg.Limits.Prompt, obsidian.Truncate),
+		},
+		Trigger: trigger,
+	})
+
+	// Count compactions in frontmatter, then append the marker
+	n, _ := strconv.Atoi(obsidian.FrontmatterField(string(content), "compactions"))
+	contentStr := obsidian.SetFrontmatterField(string(content), "compactions", strconv.Itoa(n+1))
+	atomicfile.WriteFile(sd.FilePath, []byte(contentStr+entry), 0644)
+}
+
+// logCompactSummary appends the summary the last compaction left in the
+// transcript, if it was not logged yet. The transcript offset is left alone
+// so log-response still sees the rest of the turn.
+func logCompactSummary(transcriptPath, sessionID string, sd *session.SessionData) {
+	resp, _, err := readTranscript(transcriptPath, sd.Offset, sd.LastUUID)
+	if err != nil || resp.CompactSummary == "" || resp.CompactUUID == sd.CompactUUID {
+		return
+	}
+	cfg := config.Load()
+	limiter := obsidian.Limiter{Limits: cfg.Limits, VaultDir: obsidian.VaultDir(), NotePath: sd.FilePath}
+	entry := compactSummaryEntry(resp.CompactSummary, sessionInfo(sessionID, sd), time.Now().Format("15:04:05"), cfg, limiter)
+	f, err := os.OpenFile(sd.FilePath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if _, err := f.WriteString(entry); err == nil {
+		sd.CompactUUID = resp.CompactUUID
+	}
+}
+
+// compactSummaryEntry formats a compaction summary, redacted and cut to the
+// response limit.
+func compactSummaryEntry(summary string, info obsidian.SessionInfo, timeStr string, cfg config.Config, limiter obsidian.Limiter) string {
+	return obsidian.FormatCompactSummaryEntry(obsidian.EntryInfo{
+		SessionInfo: info,
+		Time:        timeStr,
+		Text:        limiter.Fit(redact.New(cfg.Redact).Redact(summary), cfg.Limits.Response, obsidian.Truncate),
+	})
+}
+
+func runLogNotification() {
+	var input hookdata.NotificationInput
+	if err := hookdata.ReadStdin(&input); err != nil {
+		return
+	}
+	message := strings.TrimSpace(input.Message)
+	if message == "" {
+		return
+	}
+
+	unlock, _ := session.Lock(input.SessionID)
+	defer unlock()
+
+	// Notifications are only logged into an existing session note
+	sd, _ := session.Read(input.SessionID)
+	if sd == nil {
+		return
+	}
+
+	cfg := config.Load()
+	limiter := obsidian.Limiter{Limits: cfg.Limits, VaultDir: obsidian.VaultDir(), NotePath: sd.FilePath}
+	entry := obsidian.FormatNotificationEntry(obsidian.NotificationInfo{
+		EntryInfo: obsidian.EntryInfo{
+			SessionInfo: sessionInfo(input.SessionID, sd),
+			Time:        time.Now().Format("15:04:05"),
+			Text:        limiter.Fit(redact.New(cfg.Redact).Redact(message), cfg.Limits.Prompt, obsidian.Truncate),
+		},
+		Kind: input.NotificationType,
+	})
+	f, err := os.OpenFile(sd.FilePath, os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return
 	}
@@ -124,6 +408,94 @@ func runLogPrompt() {
 	f.WriteString(entry)
 }
 
+// maxSubagents caps how many logged sub-agent runs the state remembers.
+const maxSubagents = 100
+
+func runLogSubagent() {
+	var input hookdata.SubagentStopInput
+	if err := hookdata.ReadStdin(&input); err != nil {
+		return
+	}
+
+	unlock, _ := session.Lock(input.SessionID)
+	defer unlock()
+
+	// Sub-agents are only logged into an existing session note
+	sd, _ := session.Read(input.SessionID)
+	if sd == nil {
+		return
+	}
+
+	// The Task calls are in the session transcript, after the last Stop. The
+	// runs are too, unless this Claude Code gives each agent its own file.
+	runs, calls, err := readSubagents(input.TranscriptPath, sd.Offset)
+	if err != nil {
+		return
+	}
+	if input.AgentTranscriptPath != "" {
+		if runs, _, err = readSubagents(input.AgentTranscriptPath, 0); err != nil {
+			return
+		}
+	}
+	transcript.Describe(runs, calls)
+
+	logged := make(map[string]bool, len(sd.Subagents))
+	for _, id := range sd.Subagents {
+		logged[id] = true
+	}
+	cfg := config.Load()
+	redactor := redact.New(cfg.Redact)
+	limiter := obsidian.Limiter{Limits: cfg.Limits, VaultDir: obsidian.VaultDir(), NotePath: sd.FilePath}
+	info := sessionInfo(input.SessionID, sd)
+	var output strings.Builder
+	for _, run := range runs {
+		if !run.Done || logged[run.RootUUID] {
+			continue // still running (a parallel agent), or already logged
+		}
+		end := run.End
+		if end.IsZero() {
+			end = time.Now()
+		}
+		output.WriteString(obsidian.FormatSubagentEntry(obsidian.SubagentInfo{
+			EntryInfo: obsidian.EntryInfo{
+				SessionInfo: info,
+				Time:        end.Local().Format("15:04:05"),
+				Text:        limiter.Fit(redactor.Redact(run.Result), cfg.Limits.Response, obsidian.Truncate),
+			},
+			Prompt:      limiter.Fit(redactor.Redact(run.Prompt), cfg.Limits.Prompt, obsidian.Truncate),
+			Description: redactor.Redact(run.Description),
+			AgentType:   redactor.Redact(run.Type),
+		}))
+		sd.Subagents = append(sd.Subagents, run.RootUUID)
+	}
+	if output.Len() == 0 {
+		return
+	}
+
+	f, err := os.OpenFile(sd.FilePath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	f.WriteString(output.String())
+	f.Close()
+
+	if n := len(sd.Subagents); n > maxSubagents {
+		sd.Subagents = sd.Subagents[n-maxSubagents:]
+	}
+	session.Write(input.SessionID, sd)
+}
+
+// readSubagents collects the sub-agent runs and Task calls in a transcript
+// from offset on.
+func readSubagents(path string, offset int64) ([]*transcript.SubagentRun, []transcript.TaskCall, error) {
+	f, err := transcript.Open(path, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return transcript.CollectSubagents(f.Reader)
+}
+
 func runLogResponse() {
 	var input hookdata.StopInput
 	if err := hookdata.ReadStdin(&input); err != nil {
@@ -136,6 +508,9 @@ func runLogResponse() {
 		return
 	}
 
+	unlock, _ := session.Lock(input.SessionID)
+	defer unlock()
+
 	sd, _ := session.Read(input.SessionID)
 	if sd == nil {
 		return
@@ -145,23 +520,57 @@ func runLogResponse() {
 		return
 	}
 
-	// Read transcript and find last assistant text + planContent
-	responseText, planText := readTranscript(input.TranscriptPath)
+	// Read new transcript lines and collect assistant text + plan not yet logged
+	resp, offset, err := readTranscript(input.TranscriptPath, sd.Offset, sd.LastUUID)
+	if err != nil {
+		return
+	}
+	cfg := config.Load()
+	redactor := redact.New(cfg.Redact)
+	responseText := redactor.Redact(strings.Join(resp.Texts, "\n\n"))
+	planText := redactor.Redact(resp.Plan)
+
+	// Accumulate token usage and cost in session state
+	delta := obsidian.UsageFromTranscript(resp.Usage, resp.Model, cfg)
+	if !delta.IsZero() {
+		if delta.Model != "" {
+			sd.Model = delta.Model
+		}
+		sd.Tokens.Input += delta.InputTokens
+		sd.Tokens.Output += delta.OutputTokens
+		sd.Tokens.CacheRead += delta.CacheReadTokens
+		sd.Tokens.CacheCreation += delta.CacheCreationTokens
+		sd.Tokens.CostUSD += delta.CostUSD
+	}
 
 	now := time.Now()
 	timeStr := now.Format("15:04:05")
+	limiter := obsidian.Limiter{Limits: cfg.Limits, VaultDir: obsidian.VaultDir(), NotePath: filePath}
+	info := sessionInfo(input.SessionID, sd)
 	var output strings.Builder
 
+	// Log the summary of a compaction, unless session-start already did
+	if resp.CompactSummary != "" && resp.CompactUUID != sd.CompactUUID {
+		output.WriteString(compactSummaryEntry(resp.CompactSummary, info, timeStr, cfg, limiter))
+		sd.CompactUUID = resp.CompactUUID
+	}
+
 	// Log plan if found
 	if planText != "" {
-		planText = obsidian.TruncateSimple(planText, 5000)
-		output.WriteString(obsidian.FormatPlanEntry(timeStr, planText))
+		output.WriteString(obsidian.FormatPlanEntry(obsidian.EntryInfo{
+			SessionInfo: info,
+			Time:        timeStr,
+			Text:        limiter.Fit(planText, cfg.Limits.Plan, obsidian.TruncateSimple),
+		}))
 	}
 
 	// Log response
 	if responseText != "" {
-		responseText = obsidian.Truncate(responseText, 3000)
-		output.WriteString(obsidian.FormatResponseEntry(timeStr, responseText))
+		output.WriteString(obsidian.FormatResponseEntry(obsidian.EntryInfo{
+			SessionInfo: info,
+			Time:        timeStr,
+			Text:        limiter.Fit(responseText, cfg.Limits.Response, obsidian.Truncate),
+		}))
 	}
 
 	if output.Len() > 0 {
@@ -173,126 +582,285 @@ func runLogResponse() {
 		f.Close()
 	}
 
-	// Update duration in frontmatter
-	updateDuration(filePath, now)
-
-	// Rebuild daily index
-	vaultDir := obsidian.VaultDir()
-	if vaultDir != "" {
-		date := now.Format("2006-01-02")
-		obsidian.RebuildDailyIndex(vaultDir, date)
+	sd.Offset = offset
+	if resp.LastUUID != "" {
+		sd.LastUUID = resp.LastUUID
+	}
 
-		// Git sync (if enabled via config.json)
-		gitsync.SyncIfEnabled(vaultDir)
+	// Prefer Claude Code's own summary of the session as its title
+	if title := obsidian.SessionTitle(redactor.Redact(resp.Summary)); title != "" && title != sd.Title {
+		sd.Title = title
+		updateTitle(filePath, title)
+	}
+	if !delta.IsZero() {
+		updateUsage(filePath, delta)
 	}
+	sd.AddEvent(session.EventResponse, now)
+	session.Write(input.SessionID, sd)
+
+	// Update wall-clock and active duration in frontmatter
+	updateDuration(filePath, now, activeDuration(sd, cfg))
+
+	// The session is up to date; don't hold its lock through indexing and sync
+	unlock()
+
+	rebuildIndexes(obsidian.VaultDir(), sd, now, cfg)
 }
 
-// transcriptMessage represents the minimal structure we need from transcript JSONL.
-type transcriptMessage struct {
-	Type    string `json:"type"`
-	Message struct {
-		Role    string          `json:"role"`
-		Content json.RawMessage `json:"content"`
-	} `json:"message"`
-	PlanContent string `json:"planContent"`
+// activeDuration returns the session's active time so far, or -1 while it
+// has too few events to measure.
+func activeDuration(sd *session.SessionData, cfg config.Config) time.Duration {
+	if len(sd.Events) < 2 {
+		return -1
+	}
+	return session.ActiveDuration(sd.Events, time.Duration(cfg.IdleCutoff)*time.Minute)
 }
 
-type contentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+// rebuildIndexes rebuilds the daily index, the week and month rollups and
+// the project index of the session, then syncs the vault if enabled.
+func rebuildIndexes(vaultDir string, sd *session.SessionData, now time.Time, cfg config.Config) {
+	if vaultDir == "" {
+		return
+	}
+	// A session that ran past midnight is listed under both days
+	date := sd.StartTime.Format("2006-01-02")
+	obsidian.RebuildDailyIndex(vaultDir, date)
+	if today := now.Format("2006-01-02"); today != date {
+		obsidian.RebuildDailyIndex(vaultDir, today)
+	}
+	obsidian.RebuildRollups(vaultDir, date)
+	obsidian.RebuildProjectIndex(vaultDir, filepath.Base(filepath.Dir(sd.FilePath)), cfg.ProjectIndex)
+
+	// Git sync (if enabled via config.json)
+	gitsync.SyncIfEnabled(vaultDir)
 }
 
-func readTranscript(path string) (responseText, planText string) {
-	f, err := os.Open(path)
+func runLogTool() {
+	var input hookdata.ToolInput
+	if err := hookdata.ReadStdin(&input); err != nil {
+		return
+	}
+	if input.ToolName == "" {
+		return
+	}
+
+	unlock, _ := session.Lock(input.SessionID)
+	defer unlock()
+
+	// Tool calls are only logged into an existing session note
+	sd, _ := session.Read(input.SessionID)
+	if sd == nil {
+		return
+	}
+
+	timeStr := time.Now().Format("15:04:05")
+	cfg := config.Load()
+	entry := obsidian.FormatToolEntry(timeStr, input.ToolName, input.ToolInput, input.ToolResponse, cfg.Limits.Tool, redact.New(cfg.Redact))
+	f, err := os.OpenFile(sd.FilePath, os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return
 	}
 	defer f.Close()
+	f.WriteString(entry)
+}
 
-	// Read all lines into memory (we need to walk backwards)
-	var lines []string
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 1024*1024), 1024*1024) // 1MB buffer
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// runBackfill imports transcripts from before the hooks were installed.
+func runBackfill(args []string) {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	since := fs.String("since", "", "only import sessions started on or after `date` (YYYY-MM-DD)")
+	project := fs.String("project", "", "only import sessions of this project")
+	dryRun := fs.Bool("dry-run", false, "list the sessions that would be imported without writing")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
 	}
 
-	// Walk backwards, up to 50 lines
-	maxLook := 50
-	if maxLook > len(lines) {
-		maxLook = len(lines)
+	vaultDir := obsidian.VaultDir()
+	if vaultDir == "" {
+		fmt.Fprintln(os.Stderr, "backfill: CLAUDE_VAULT is not set")
+		os.Exit(1)
 	}
-
-	for i := len(lines) - 1; i >= len(lines)-maxLook; i-- {
-		if i < 0 {
-			break
-		}
-		line := strings.TrimSpace(lines[i])
-		if line == "" {
-			continue
-		}
-		var msg transcriptMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			continue
+	opts := backfill.Options{
+		ClaudeProjectsDir: filepath.Join(config.ClaudeDir(), "projects"),
+		VaultDir:          vaultDir,
+		Project:           *project,
+		DryRun:            *dryRun,
+		Config:            config.Load(),
+		Log:               os.Stdout,
+	}
+	if *since != "" {
+		t, err := time.ParseInLocation("2006-01-02", *since, time.Local)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "backfill: invalid --since %q, want YYYY-MM-DD\n", *since)
+			os.Exit(2)
 		}
+		opts.Since = t
+	}
 
-		// Check for plan content
-		if planText == "" && msg.PlanContent != "" {
-			planText = msg.PlanContent
-		}
+	res, err := backfill.Run(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backfill: %v\n", err)
+		os.Exit(1)
+	}
+	verb := "Imported"
+	if *dryRun {
+		verb = "Would import"
+	}
+	fmt.Printf("%s %d sessions over %d days (%d already in the vault)\n", verb, res.Imported, len(res.Dates), res.Skipped)
+}
 
-		// Check for assistant text response
-		if responseText == "" && msg.Type == "assistant" && msg.Message.Role == "assistant" {
-			var blocks []contentBlock
-			if err := json.Unmarshal(msg.Message.Content, &blocks); err != nil {
-				continue
-			}
-			var texts []string
-			for _, b := range blocks {
-				if b.Type == "text" && b.Text != "" {
-					texts = append(texts, b.Text)
-				}
-			}
-			if len(texts) > 0 {
-				responseText = strings.Join(texts, "\n\n")
-				break
-			}
+// reindexLockWait bounds how long reindex waits for another run to finish.
+const reindexLockWait = 30 * time.Second
+
+func runReindex(args []string) {
+	fs := flag.NewFlagSet("reindex", flag.ContinueOnError)
+	date := fs.String("date", "", "only rebuild the indexes covering `date` (YYYY-MM-DD)")
+	from := fs.String("from", "", "only rebuild indexes covering days on or after `date`")
+	to := fs.String("to", "", "only rebuild indexes covering days on or before `date`")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	if *date != "" {
+		*from, *to = *date, *date
+	}
+	for _, d := range []string{*from, *to} {
+		if _, err := time.Parse("2006-01-02", d); d != "" && err != nil {
+			fmt.Fprintf(os.Stderr, "reindex: invalid date %q, want YYYY-MM-DD\n", d)
+			os.Exit(2)
 		}
 	}
-	return
+
+	vaultDir := obsidian.VaultDir()
+	if vaultDir == "" {
+		fmt.Fprintln(os.Stderr, "reindex: CLAUDE_VAULT is not set")
+		os.Exit(1)
+	}
+	// Overlapping cron runs would report each other's writes; run one at a time
+	os.MkdirAll(session.Dir(), 0755)
+	lock, err := filelock.Acquire(filepath.Join(session.Dir(), "reindex.lock"), reindexLockWait)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reindex: another reindex is running: %v\n", err)
+		os.Exit(1)
+	}
+	defer lock.Unlock()
+
+	report, err := obsidian.Reindex(vaultDir, *from, *to, config.Load().ProjectIndex)
+	for _, c := range report.Changes {
+		fmt.Printf("%-8s %s\n", c.Action, c.Path)
+	}
+	fmt.Printf("%d changed, %d unchanged\n", len(report.Changes), report.Unchanged)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reindex: %v\n", err)
+		lock.Unlock()
+		os.Exit(1)
+	}
+	if len(report.Changes) > 0 {
+		gitsync.SyncIfEnabled(vaultDir)
+	}
 }
 
-func updateDuration(filePath string, now time.Time) {
+// readTranscript collects the assistant output appended to the transcript
+// since offset and returns it with the offset to resume from next time.
+func readTranscript(path string, offset int64, lastUUID string) (transcript.Response, int64, error) {
+	f, err := transcript.Open(path, offset)
+	if err != nil {
+		return transcript.Response{}, offset, err
+	}
+	defer f.Close()
+	resp, err := transcript.CollectResponse(f.Reader, lastUUID)
+	return resp, f.Offset(), err
+}
+
+// sessionInfo returns the template data of a session from its state.
+func sessionInfo(sessionID string, sd *session.SessionData) obsidian.SessionInfo {
+	return obsidian.SessionInfo{
+		Date:      sd.StartTime.Format("2006-01-02"),
+		SessionID: sessionID,
+		Project:   sd.Project,
+		Title:     sd.Title,
+		Cwd:       sd.Cwd,
+		GitBranch: sd.GitBranch,
+		StartTime: sd.StartTime.Format("15:04"),
+		StartedAt: sd.StartTime.Format(time.RFC3339),
+		PromptNum: sd.PromptNum,
+		Model:     sd.Model,
+		Tokens: obsidian.Usage{
+			Model:               sd.Model,
+			InputTokens:         sd.Tokens.Input,
+			OutputTokens:        sd.Tokens.Output,
+			CacheReadTokens:     sd.Tokens.CacheRead,
+			CacheCreationTokens: sd.Tokens.CacheCreation,
+			CostUSD:             sd.Tokens.CostUSD,
+		},
+	}
+}
+
+// updateUsage adds delta to the totals in the session note's frontmatter.
+func updateUsage(filePath string, delta obsidian.Usage) {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return
 	}
-	contentStr := string(content)
+	atomicfile.WriteFile(filePath, []byte(obsidian.AddUsage(string(content), delta)), 0644)
+}
+
+// updateTitle sets the session note's title in frontmatter and heading.
+func updateTitle(filePath, title string) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return
+	}
+	atomicfile.WriteFile(filePath, []byte(obsidian.SetTitle(string(content), title)), 0644)
+}
 
-	m := startTimeRe.FindStringSubmatch(contentStr)
-	if len(m) < 2 {
+// updateEnd marks the session note as ended, recording when, why and the
+// final prompt count.
+func updateEnd(filePath string, end time.Time, reason string, prompts int) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
 		return
 	}
+	contentStr := obsidian.SetFrontmatterField(string(content), "status", obsidian.StatusEnded)
+	contentStr = obsidian.SetFrontmatterField(contentStr, "end_time", end.Format("15:04"))
+	contentStr = obsidian.SetFrontmatterField(contentStr, "ended_at", end.Format(time.RFC3339))
+	if reason != "" {
+		contentStr = obsidian.SetFrontmatterField(contentStr, "end_reason", reason)
+	}
+	contentStr = obsidian.SetFrontmatterField(contentStr, "prompts", strconv.Itoa(prompts))
+	atomicfile.WriteFile(filePath, []byte(contentStr), 0644)
+}
 
-	startStr := m[1]
-	today := now.Format("2006-01-02")
-	startDt, err := time.ParseInLocation("2006-01-02 15:04", today+" "+startStr, time.Local)
+// updateDuration sets the wall-clock duration from the note's start to now
+// and, unless active is negative, the active duration.
+func updateDuration(filePath string, now time.Time, active time.Duration) {
+	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return
 	}
+	contentStr := string(content)
 
-	dur := now.Sub(startDt)
-	totalMin := int(math.Floor(dur.Minutes()))
-	if totalMin < 1 {
-		totalMin = 1
+	startDt, ok := obsidian.NoteStart(contentStr)
+	if !ok {
+		return
 	}
-	durStr := fmt.Sprintf("%dmin", totalMin)
+	durStr := minutesValue(now.Sub(startDt))
 
 	if durationLineRe.MatchString(contentStr) {
 		contentStr = durationLineRe.ReplaceAllString(contentStr, "duration: "+durStr)
 	} else {
 		contentStr = startTimeLineRe.ReplaceAllString(contentStr, "${1}\nduration: "+durStr)
 	}
+	if active >= 0 {
+		contentStr = obsidian.SetFrontmatterField(contentStr, "active_duration", minutesValue(active))
+	}
+
+	atomicfile.WriteFile(filePath, []byte(contentStr), 0644)
+}
 
-	os.WriteFile(filePath, []byte(contentStr), 0644)
+// minutesValue renders d as a frontmatter duration in whole minutes, at least 1.
+func minutesValue(d time.Duration) string {
+	totalMin := int(math.Floor(d.Minutes()))
+	if totalMin < 1 {
+		totalMin = 1
+	}
+	return fmt.Sprintf("%dmin", totalMin)
 }
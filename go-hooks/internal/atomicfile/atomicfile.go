@@ -0,0 +1,44 @@
+// Package atomicfile replaces files so that readers never see them half
+// written.
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFile replaces path with data so that readers (Obsidian, sync
+// clients) see either the old or the new content, never a truncated file,
+// even if the hook is killed mid-write. The data goes to a hidden sibling
+// temp file which is fsynced and renamed over path. If the rename fails
+// (e.g. another program holds the target open on Windows), it falls back to
+// writing path in place.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(path)
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return os.WriteFile(path, data, perm)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteFile_Replaces verifies content is replaced and no temp files linger.
+func TestWriteFile_Replaces(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note.md")
+	os.WriteFile(path, []byte("old content that is longer"), 0644)
+
+	if err := WriteFile(path, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("got %q, want %q", data, "new")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("expected only note.md in dir, got %v", names)
+	}
+}
+
+// TestWriteFile_Creates verifies a missing target is created.
+func TestWriteFile_Creates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new.md")
+	if err := WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "hello" {
+		t.Errorf("got %q", data)
+	}
+}
+
+// TestWriteFile_MissingDir verifies an error is returned and nothing is written.
+func TestWriteFile_MissingDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nope", "note.md")
+	if err := WriteFile(path, []byte("x"), 0644); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
@@ -0,0 +1,308 @@
+// Package backfill imports Claude Code transcripts that predate the hooks
+// into the vault, producing the same notes the hooks would have written.
+package backfill
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/valentinclaes/claude-hooks/internal/atomicfile"
+	"github.com/valentinclaes/claude-hooks/internal/config"
+	"github.com/valentinclaes/claude-hooks/internal/obsidian"
+	"github.com/valentinclaes/claude-hooks/internal/redact"
+	"github.com/valentinclaes/claude-hooks/internal/session"
+	"github.com/valentinclaes/claude-hooks/internal/transcript"
+)
+
+// Options selects the transcripts to import.
+type Options struct {
+	ClaudeProjectsDir string // ~/.claude/projects
+	VaultDir          string
+	Since             time.Time // skip sessions started before; zero imports all
+	Project           string    // only import this project (case-insensitive); "" imports all
+	DryRun            bool      // report what would be imported without writing
+	Config            config.Config
+	Log               io.Writer // receives one line per session; may be nil
+}
+
+// Result summarizes a backfill run.
+type Result struct {
+	Imported int
+	Skipped  int      // already in the vault
+	Dates    []string // days with imported sessions, sorted
+}
+
+// turn is one prompt and the assistant output that answered it.
+type turn struct {
+	time     time.Time
+	cwd      string
+	prompt   string
+	plan     string
+	texts    []string
+	answered time.Time // time of the last assistant message
+}
+
+// transcriptSession is a transcript reconstructed into turns.
+type transcriptSession struct {
+	id        string
+	cwd       string
+	gitBranch string
+	summary   string
+	model     string
+	parentID  string // parentUuid near the start, linking a resumed session
+	start     time.Time
+	end       time.Time
+	turns     []turn
+	usage     map[string]transcript.Usage
+}
+
+// Run imports every transcript under opts.ClaudeProjectsDir whose session is
+// not yet in the vault, then rebuilds the indexes of the affected days.
+func Run(opts Options) (Result, error) {
+	var res Result
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+	paths, err := filepath.Glob(filepath.Join(opts.ClaudeProjectsDir, "*", "*.jsonl"))
+	if err != nil {
+		return res, err
+	}
+	existing := obsidian.SessionNotes(opts.VaultDir)
+	redactor := redact.New(opts.Config.Redact)
+	dates := make(map[string]bool)
+	projects := make(map[string]bool)
+
+	// Import oldest first, so resumed sessions can link to their parent
+	var sessions []*transcriptSession
+	for _, path := range paths {
+		s, err := parse(path, strings.TrimSuffix(filepath.Base(path), ".jsonl"))
+		if err != nil || len(s.turns) == 0 {
+			continue
+		}
+		if !opts.Since.IsZero() && s.start.Before(opts.Since) {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].start.Before(sessions[j].start) })
+
+	for _, s := range sessions {
+		id := s.id
+		project := obsidian.SanitizeProject(filepath.Base(s.cwd))
+		if opts.Project != "" && !strings.EqualFold(project, opts.Project) {
+			continue
+		}
+		if note, ok := existing[id]; ok {
+			res.Skipped++
+			fmt.Fprintf(opts.Log, "skip    %s (already in %s)\n", id, note)
+			continue
+		}
+
+		projectDir := filepath.Join(opts.VaultDir, project)
+		title := obsidian.SessionTitle(redactor.Redact(s.summary))
+		if title == "" {
+			title = obsidian.SessionTitle(redactor.Redact(s.turns[0].prompt))
+		}
+		notePath := obsidian.NewNotePath(projectDir, s.start, title, opts.Config.SlugFilenames)
+		rel, _ := filepath.Rel(opts.VaultDir, notePath)
+		fmt.Fprintf(opts.Log, "import  %s -> %s (%d prompts)\n", id, filepath.ToSlash(rel), len(s.turns))
+
+		res.Imported++
+		dates[s.start.Format("2006-01-02")] = true
+		// A session that ran past midnight is also listed under the day it ended
+		dates[s.end.Format("2006-01-02")] = true
+		projects[project] = true
+		if opts.DryRun {
+			continue
+		}
+		if err := os.MkdirAll(projectDir, 0755); err != nil {
+			return res, err
+		}
+		resumedFrom := ""
+		if parent, ok := existing[s.parentID]; ok && s.parentID != "" {
+			if rel, err := filepath.Rel(opts.VaultDir, parent); err == nil {
+				resumedFrom = strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+			}
+		}
+		content := render(s, project, title, resumedFrom, notePath, opts, redactor)
+		if err := atomicfile.WriteFile(notePath, []byte(content), 0644); err != nil {
+			return res, err
+		}
+		existing[id] = notePath
+	}
+
+	for d := range dates {
+		res.Dates = append(res.Dates, d)
+	}
+	sort.Strings(res.Dates)
+	if opts.DryRun {
+		return res, nil
+	}
+	for _, d := range res.Dates {
+		obsidian.RebuildDailyIndex(opts.VaultDir, d)
+		obsidian.RebuildRollups(opts.VaultDir, d)
+	}
+	for p := range projects {
+		obsidian.RebuildProjectIndex(opts.VaultDir, p, opts.Config.ProjectIndex)
+	}
+	return res, nil
+}
+
+// parse reads a transcript into turns. Sub-agent traffic only contributes
+// its token usage, as in the live hooks.
+func parse(path, id string) (*transcriptSession, error) {
+	f, err := transcript.Open(path, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := &transcriptSession{id: id}
+	n := 0
+	// Usage is repeated on every content-block line of a message; keep the last.
+	byMessage := make(map[string]transcript.Usage)
+	messageModel := make(map[string]string)
+	for f.Next() {
+		e := f.Entry()
+		if n++; n <= 20 && s.parentID == "" {
+			s.parentID = e.ParentUUID // same lookup as obsidian.FindParentSession
+		}
+		if !e.Timestamp.IsZero() {
+			if s.start.IsZero() {
+				s.start = e.Timestamp.Local()
+			}
+			s.end = e.Timestamp.Local()
+		}
+		if e.Type == transcript.TypeSummary && e.Summary != "" {
+			s.summary = e.Summary
+		}
+		if e.Type == transcript.TypeAssistant && e.Message != nil && e.Message.Usage != nil {
+			msgID := e.Message.ID
+			if msgID == "" {
+				msgID = e.UUID
+			}
+			byMessage[msgID] = *e.Message.Usage
+			messageModel[msgID] = e.Message.Model
+		}
+		if e.IsSidechain {
+			continue
+		}
+
+		if e.IsPrompt() {
+			prompt := obsidian.StripSystemTags(e.Text())
+			if prompt == "" {
+				continue
+			}
+			if s.cwd == "" {
+				s.cwd, s.gitBranch = e.Cwd, e.GitBranch
+				s.start = e.Timestamp.Local()
+			}
+			s.turns = append(s.turns, turn{time: e.Timestamp.Local(), cwd: e.Cwd, prompt: prompt, plan: e.PlanContent})
+			continue
+		}
+		if len(s.turns) == 0 {
+			continue
+		}
+		t := &s.turns[len(s.turns)-1]
+		if e.PlanContent != "" {
+			t.plan = e.PlanContent
+		}
+		if e.Type == transcript.TypeAssistant {
+			t.answered = e.Timestamp.Local()
+			if text := e.Text(); text != "" {
+				t.texts = append(t.texts, text)
+			}
+			if m := e.Message; m != nil && m.Model != "" && m.Model != transcript.SyntheticModel {
+				s.model = m.Model
+			}
+		}
+	}
+
+	s.usage = make(map[string]transcript.Usage)
+	for msgID, u := range byMessage {
+		total := s.usage[messageModel[msgID]]
+		total.Add(u)
+		s.usage[messageModel[msgID]] = total
+	}
+	return s, f.Err()
+}
+
+// render builds the complete note for a reconstructed session with the same
+// templates, redaction and limits as the live hooks.
+func render(s *transcriptSession, project, title, resumedFrom, notePath string, opts Options, redactor *redact.Redactor) string {
+	cfg := opts.Config
+	limiter := obsidian.Limiter{Limits: cfg.Limits, VaultDir: opts.VaultDir, NotePath: notePath}
+	usage := obsidian.UsageFromTranscript(s.usage, s.model, cfg)
+	info := obsidian.SessionInfo{
+		Date:        s.start.Format("2006-01-02"),
+		SessionID:   s.id,
+		Project:     project,
+		Title:       title,
+		Cwd:         s.cwd,
+		GitBranch:   s.gitBranch,
+		StartTime:   s.start.Format("15:04"),
+		StartedAt:   s.start.Format(time.RFC3339),
+		Model:       s.model,
+		Tokens:      usage,
+		ResumedFrom: resumedFrom,
+	}
+
+	var sb strings.Builder
+	sb.WriteString(obsidian.BuildFrontmatter(info))
+	for i, t := range s.turns {
+		info.PromptNum = i + 1
+		info.Cwd = t.cwd
+		sb.WriteString(obsidian.FormatPromptEntry(obsidian.EntryInfo{
+			SessionInfo: info,
+			Time:        t.time.Format("15:04:05"),
+			Text:        limiter.Fit(redactor.Redact(t.prompt), cfg.Limits.Prompt, obsidian.Truncate),
+		}))
+		answered := t.answered
+		if answered.IsZero() {
+			answered = t.time
+		}
+		timeStr := answered.Format("15:04:05")
+		if plan := redactor.Redact(t.plan); plan != "" {
+			sb.WriteString(obsidian.FormatPlanEntry(obsidian.EntryInfo{
+				SessionInfo: info,
+				Time:        timeStr,
+				Text:        limiter.Fit(plan, cfg.Limits.Plan, obsidian.TruncateSimple),
+			}))
+		}
+		if text := redactor.Redact(strings.Join(t.texts, "\n\n")); text != "" {
+			sb.WriteString(obsidian.FormatResponseEntry(obsidian.EntryInfo{
+				SessionInfo: info,
+				Time:        timeStr,
+				Text:        limiter.Fit(text, cfg.Limits.Response, obsidian.Truncate),
+			}))
+		}
+	}
+
+	content := sb.String()
+	if !usage.IsZero() {
+		content = obsidian.AddUsage(content, usage)
+	}
+	minutes := int(s.end.Sub(s.start).Minutes())
+	if minutes < 1 {
+		minutes = 1
+	}
+	content = obsidian.SetFrontmatterField(content, "duration", fmt.Sprintf("%dmin", minutes))
+
+	var events []session.Event
+	for _, t := range s.turns {
+		events = append(events, session.Event{Time: t.time, Kind: session.EventPrompt})
+		if !t.answered.IsZero() {
+			events = append(events, session.Event{Time: t.answered, Kind: session.EventResponse})
+		}
+	}
+	active := int(session.ActiveDuration(events, time.Duration(cfg.IdleCutoff)*time.Minute).Minutes())
+	if active < 1 {
+		active = 1
+	}
+	return obsidian.SetFrontmatterField(content, "active_duration", fmt.Sprintf("%dmin", active))
+}
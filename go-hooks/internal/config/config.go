@@ -4,29 +4,150 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // Config holds hook settings from ~/.claude/hooks/config.json.
 type Config struct {
 	SkipWhenFocused bool `json:"skip_when_focused"`
 	GitAutoPush     bool `json:"git_auto_push"`
+	// Prices maps a model name fragment (e.g. "sonnet", "opus-4-5") to its
+	// token prices. Entries in config.json are merged over the defaults.
+	Prices map[string]ModelPrice `json:"prices"`
+	// Redact controls secret redaction of everything written to the vault.
+	Redact Redact `json:"redact"`
+	// Limits caps how much of each entry kind is written into the note.
+	Limits Limits `json:"limits"`
+	// ProjectIndex is the name (without .md) of the index note generated in
+	// each project folder. Empty disables it.
+	ProjectIndex string `json:"project_index"`
+	// SlugFilenames appends a slug of the session title to new note file
+	// names (2026-02-13_1350_fix-login-redirect.md).
+	SlugFilenames bool `json:"slug_filenames"`
+	// IdleCutoff is the number of minutes without a prompt after which the
+	// user counts as away: longer gaps are left out of active_duration.
+	IdleCutoff int `json:"idle_cutoff_min"`
+}
+
+// Overflow modes for text over its limit.
+const (
+	OverflowTruncate   = "truncate"   // cut the text and note its full length
+	OverflowAttachment = "attachment" // move the full text to an attachment note
+)
+
+// Limits sets the maximum length of each entry kind. In attachment mode, the
+// full text of an overlong prompt, response or plan is written to
+// {project}/attachments/ and linked from the callout; Embed selects ![[...]]
+// (shown inline) over [[...]]. Tool output is always truncated.
+type Limits struct {
+	Prompt   int    `json:"prompt"`
+	Response int    `json:"response"`
+	Plan     int    `json:"plan"`
+	Tool     int    `json:"tool"`
+	Overflow string `json:"overflow"`
+	Embed    bool   `json:"embed"`
+}
+
+// Redact configures secret redaction. Built-in detectors always run while
+// Enabled; Patterns adds user-defined regexes on top of them.
+type Redact struct {
+	Enabled  bool            `json:"enabled"`
+	Patterns []RedactPattern `json:"patterns"`
+}
+
+// RedactPattern is a user-defined redaction rule. Matches of Regex are
+// replaced with [REDACTED:Name]; if Regex has a capture group, only the
+// first group is replaced (e.g. the value of key=value).
+type RedactPattern struct {
+	Name  string `json:"name"`
+	Regex string `json:"regex"`
+}
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
 }
 
 func defaults() Config {
 	return Config{
 		SkipWhenFocused: true,
 		GitAutoPush:     false,
+		Prices: map[string]ModelPrice{
+			"opus":      {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+			"opus-4-5":  {Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25},
+			"sonnet":    {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+			"haiku":     {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+			"haiku-4-5": {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+		},
+		Redact: Redact{Enabled: true},
+		Limits: Limits{
+			Prompt:   5000,
+			Response: 3000,
+			Plan:     5000,
+			Tool:     2000,
+			Overflow: OverflowTruncate,
+			Embed:    true,
+		},
+		ProjectIndex: "_index",
+		IdleCutoff:   15,
+	}
+}
+
+// PriceFor returns the price of model, matched by the longest configured
+// fragment contained in the model name. ok is false for unknown models.
+func (c Config) PriceFor(model string) (price ModelPrice, ok bool) {
+	model = strings.ToLower(model)
+	best := -1
+	for key, p := range c.Prices {
+		if len(key) > best && strings.Contains(model, strings.ToLower(key)) {
+			price, ok, best = p, true, len(key)
+		}
 	}
+	return price, ok
+}
+
+// Cost returns the USD cost of the given token counts.
+func (p ModelPrice) Cost(input, output, cacheRead, cacheWrite int64) float64 {
+	return (float64(input)*p.Input +
+		float64(output)*p.Output +
+		float64(cacheRead)*p.CacheRead +
+		float64(cacheWrite)*p.CacheWrite) / 1e6
+}
+
+// ClaudeDir returns Claude Code's config directory: $CLAUDE_CONFIG_DIR if
+// set, otherwise ~/.claude. Returns "" if the home directory is unknown.
+func ClaudeDir() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".claude")
+}
+
+// HooksDir returns the directory the hooks are installed in (~/.claude/hooks).
+// Returns "" if the home directory is unknown.
+func HooksDir() string {
+	dir := ClaudeDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "hooks")
 }
 
 // Load reads config from ~/.claude/hooks/config.json.
 // Returns defaults on any error (missing file, bad JSON, etc.).
 func Load() Config {
-	home, err := os.UserHomeDir()
-	if err != nil {
+	dir := HooksDir()
+	if dir == "" {
 		return defaults()
 	}
-	return loadFrom(filepath.Join(home, ".claude", "hooks", "config.json"))
+	return loadFrom(filepath.Join(dir, "config.json"))
 }
 
 func loadFrom(path string) Config {
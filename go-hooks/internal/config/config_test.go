@@ -57,3 +57,97 @@ func TestLoad_MalformedJSON(t *testing.T) {
 		t.Error("expected GitAutoPush=false for malformed JSON")
 	}
 }
+
+func TestLoad_PricesMergedOverDefaults(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	os.WriteFile(path, []byte(`{"prices": {"sonnet": {"input": 1, "output": 2}, "my-model": {"input": 9}}}`), 0644)
+
+	cfg := loadFrom(path)
+	if p, _ := cfg.PriceFor("claude-sonnet-4-5"); p.Input != 1 || p.Output != 2 {
+		t.Errorf("expected overridden sonnet price, got %+v", p)
+	}
+	if p, _ := cfg.PriceFor("claude-opus-4-1"); p.Input != 15 {
+		t.Errorf("expected default opus price to survive merge, got %+v", p)
+	}
+	if p, ok := cfg.PriceFor("my-model-v2"); !ok || p.Input != 9 {
+		t.Errorf("expected custom model price, got %+v (ok=%v)", p, ok)
+	}
+}
+
+func TestPriceFor_LongestMatchWins(t *testing.T) {
+	cfg := defaults()
+	if p, _ := cfg.PriceFor("claude-opus-4-5-20251101"); p.Input != 5 {
+		t.Errorf("expected opus-4-5 price, got %+v", p)
+	}
+	if _, ok := cfg.PriceFor("gpt-4"); ok {
+		t.Error("expected unknown model to have no price")
+	}
+}
+
+func TestModelPrice_Cost(t *testing.T) {
+	p := ModelPrice{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}
+	got := p.Cost(1_000_000, 100_000, 2_000_000, 0)
+	if want := 3 + 1.5 + 0.6; got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("Cost: got %v, want %v", got, want)
+	}
+}
+
+func TestClaudeDir_DefaultsToHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("USERPROFILE", home) // os.UserHomeDir() reads this on Windows
+	t.Setenv("HOME", home)        // os.UserHomeDir() reads this on Unix
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+
+	if got, want := ClaudeDir(), filepath.Join(home, ".claude"); got != want {
+		t.Errorf("ClaudeDir: got %q, want %q", got, want)
+	}
+	if got, want := HooksDir(), filepath.Join(home, ".claude", "hooks"); got != want {
+		t.Errorf("HooksDir: got %q, want %q", got, want)
+	}
+}
+
+func TestClaudeDir_HonorsEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", dir)
+
+	if got := ClaudeDir(); got != dir {
+		t.Errorf("ClaudeDir: got %q, want %q", got, dir)
+	}
+
+	os.MkdirAll(filepath.Join(dir, "hooks"), 0755)
+	os.WriteFile(filepath.Join(dir, "hooks", "config.json"), []byte(`{"git_auto_push": true}`), 0644)
+	if !Load().GitAutoPush {
+		t.Error("expected Load to read config.json from CLAUDE_CONFIG_DIR")
+	}
+}
+
+func TestLoad_RedactDefaultsAndPatterns(t *testing.T) {
+	if !defaults().Redact.Enabled {
+		t.Error("expected redaction enabled by default")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	os.WriteFile(path, []byte(`{"redact": {"patterns": [{"name": "ticket", "regex": "TKT-\\d+"}]}}`), 0644)
+
+	cfg := loadFrom(path)
+	if !cfg.Redact.Enabled {
+		t.Error("expected Enabled to stay true when only patterns are given")
+	}
+	if len(cfg.Redact.Patterns) != 1 || cfg.Redact.Patterns[0].Name != "ticket" || cfg.Redact.Patterns[0].Regex != `TKT-\d+` {
+		t.Errorf("unexpected patterns: %+v", cfg.Redact.Patterns)
+	}
+}
+
+func TestLoad_LimitsMergedOverDefaults(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	os.WriteFile(path, []byte(`{"limits": {"response": 20000, "overflow": "attachment"}}`), 0644)
+
+	cfg := loadFrom(path)
+	want := Limits{Prompt: 5000, Response: 20000, Plan: 5000, Tool: 2000, Overflow: OverflowAttachment, Embed: true}
+	if cfg.Limits != want {
+		t.Errorf("limits: got %+v, want %+v", cfg.Limits, want)
+	}
+}
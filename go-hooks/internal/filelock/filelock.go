@@ -0,0 +1,87 @@
+// Package filelock provides exclusive advisory locks between hook processes.
+//
+// It uses flock on Unix and LockFileEx on Windows. Where neither is available
+// (or the filesystem rejects them) it falls back to an O_EXCL lock file, the
+// same scheme gitsync uses for its sync lock.
+package filelock
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// staleAfter is the age at which a fallback lock file is assumed abandoned.
+// OS locks need no such rule: they are released when the process exits.
+const staleAfter = 2 * time.Minute
+
+const retryInterval = 10 * time.Millisecond
+
+// ErrTimeout is returned when the lock could not be acquired in time.
+var ErrTimeout = errors.New("filelock: timed out waiting for lock")
+
+// errLocked is returned by tryLock when another holder has the lock.
+var errLocked = errors.New("filelock: locked")
+
+// Lock is a held lock. Release it with Unlock.
+type Lock struct {
+	f        *os.File
+	fallback string // path of the fallback lock file, if used
+}
+
+// Acquire takes an exclusive lock on path, creating the file if needed and
+// waiting up to timeout for other holders to release it.
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(timeout)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err := tryLock(f)
+		if err == nil {
+			return &Lock{f: f}, nil
+		}
+		if err != errLocked {
+			// OS locking unsupported here: use a lock file instead.
+			f.Close()
+			return acquireFallback(path+".lck", deadline)
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, ErrTimeout
+		}
+		time.Sleep(retryInterval)
+	}
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	if l.fallback != "" {
+		return os.Remove(l.fallback)
+	}
+	unlock(l.f)
+	return l.f.Close()
+}
+
+func acquireFallback(path string, deadline time.Time) (*Lock, error) {
+	for {
+		// Check for stale lock
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleAfter {
+			os.Remove(path)
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return &Lock{fallback: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrTimeout
+		}
+		time.Sleep(retryInterval)
+	}
+}
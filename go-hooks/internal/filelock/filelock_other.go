@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package filelock
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock reports OS locking as unsupported so Acquire uses a lock file.
+func tryLock(f *os.File) error {
+	return errors.New("filelock: not supported on this platform")
+}
+
+func unlock(f *os.File) {}
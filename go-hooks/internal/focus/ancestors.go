@@ -0,0 +1,21 @@
+package focus
+
+// getAncestors walks up the process tree from startPID, returning all ancestor PIDs.
+// Stops at depth 20 or on cycle/missing parent.
+func getAncestors(startPID uint32, parentOf map[uint32]uint32) []uint32 {
+	var ancestors []uint32
+	seen := make(map[uint32]bool)
+	seen[startPID] = true
+	current := startPID
+
+	for i := 0; i < 20; i++ {
+		parent, ok := parentOf[current]
+		if !ok || parent == 0 || seen[parent] {
+			break
+		}
+		ancestors = append(ancestors, parent)
+		seen[parent] = true
+		current = parent
+	}
+	return ancestors
+}
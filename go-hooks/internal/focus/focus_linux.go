@@ -0,0 +1,206 @@
+package focus
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const commandTimeout = time.Second
+
+var errNoDisplay = errors.New("no X display")
+
+// windowProvider reports the PID owning the focused desktop window.
+type windowProvider interface {
+	ActivePID() (uint32, error)
+}
+
+// detector decides whether our terminal is focused. Its dependencies are
+// injectable so the logic can be tested without X11, tmux or /proc.
+type detector struct {
+	pid       uint32
+	processes func() (map[uint32]uint32, error)
+	window    windowProvider
+	tmuxPane  string
+	tmux      func(args ...string) (string, error)
+}
+
+// TerminalIsFocused returns true if the focused X11 window belongs to an
+// ancestor of the current process, or when running in tmux, if our pane is
+// the active one of an attached session whose client's terminal is focused.
+// Returns false on any error (fail-open: show the notification).
+func TerminalIsFocused() bool {
+	d := detector{
+		pid:       uint32(os.Getpid()),
+		processes: func() (map[uint32]uint32, error) { return buildProcessMap("/proc") },
+		window:    xpropWindow{},
+		tmuxPane:  os.Getenv("TMUX_PANE"),
+		tmux:      runTmux,
+	}
+	return d.focused()
+}
+
+func (d detector) focused() bool {
+	// Inside tmux our ancestry ends at the tmux server, not the terminal;
+	// the terminal is an ancestor of the attached client instead.
+	origins := []uint32{d.pid}
+	if d.tmuxPane != "" {
+		clients, ok := d.tmuxClients()
+		if !ok {
+			return false
+		}
+		origins = clients
+	}
+
+	fgPID, err := d.window.ActivePID()
+	if err != nil || fgPID == 0 {
+		// No desktop window to compare against (console, SSH, Wayland
+		// without XWayland): the active tmux pane is the best signal left.
+		return d.tmuxPane != "" && errors.Is(err, errNoDisplay)
+	}
+
+	parentOf, err := d.processes()
+	if err != nil {
+		return false
+	}
+	for _, origin := range origins {
+		if origin == fgPID {
+			return true
+		}
+		for _, a := range getAncestors(origin, parentOf) {
+			if a == fgPID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tmuxClients returns the PIDs of the clients attached to our pane's session,
+// and false if the pane is not the one currently displayed.
+func (d detector) tmuxClients() ([]uint32, bool) {
+	out, err := d.tmux("display-message", "-p", "-t", d.tmuxPane,
+		"#{pane_active} #{window_active} #{session_attached} #{session_id}")
+	if err != nil {
+		return nil, false
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 4 || fields[0] != "1" || fields[1] != "1" || fields[2] == "0" {
+		return nil, false
+	}
+
+	out, err = d.tmux("list-clients", "-t", fields[3], "-F", "#{client_pid}")
+	if err != nil {
+		return nil, false
+	}
+	var pids []uint32
+	for _, line := range strings.Fields(out) {
+		if pid, err := strconv.ParseUint(line, 10, 32); err == nil {
+			pids = append(pids, uint32(pid))
+		}
+	}
+	return pids, len(pids) > 0
+}
+
+func runTmux(args ...string) (string, error) {
+	return run("tmux", args...)
+}
+
+func run(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	return string(out), err
+}
+
+// xpropWindow reads _NET_ACTIVE_WINDOW and its _NET_WM_PID via xprop.
+type xpropWindow struct{}
+
+func (xpropWindow) ActivePID() (uint32, error) {
+	if os.Getenv("DISPLAY") == "" {
+		return 0, errNoDisplay
+	}
+	out, err := run("xprop", "-root", "_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return 0, err
+	}
+	winID, err := parseActiveWindow(out)
+	if err != nil {
+		return 0, err
+	}
+	out, err = run("xprop", "-id", winID, "_NET_WM_PID")
+	if err != nil {
+		return 0, err
+	}
+	return parseWindowPID(out)
+}
+
+// parseActiveWindow parses "_NET_ACTIVE_WINDOW(WINDOW): window id # 0x3a00007".
+func parseActiveWindow(out string) (string, error) {
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return "", errors.New("empty xprop output")
+	}
+	id := strings.TrimSuffix(fields[len(fields)-1], ",")
+	if !strings.HasPrefix(id, "0x") || id == "0x0" {
+		return "", errors.New("no active window")
+	}
+	return id, nil
+}
+
+// parseWindowPID parses "_NET_WM_PID(CARDINAL) = 12345".
+func parseWindowPID(out string) (uint32, error) {
+	_, val, ok := strings.Cut(out, "=")
+	if !ok {
+		return 0, errors.New("window has no _NET_WM_PID")
+	}
+	pid, err := strconv.ParseUint(strings.TrimSpace(val), 10, 32)
+	return uint32(pid), err
+}
+
+// buildProcessMap maps every PID under procRoot to its parent PID.
+func buildProcessMap(procRoot string) (map[uint32]uint32, error) {
+	dirs, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	parentOf := make(map[uint32]uint32)
+	for _, d := range dirs {
+		pid, err := strconv.ParseUint(d.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(procRoot, d.Name(), "stat"))
+		if err != nil {
+			continue // process exited
+		}
+		if ppid, ok := parseStatPPID(string(data)); ok {
+			parentOf[uint32(pid)] = ppid
+		}
+	}
+	return parentOf, nil
+}
+
+// parseStatPPID extracts the parent PID from /proc/<pid>/stat
+// ("pid (comm) state ppid ..."). comm may contain spaces and parentheses,
+// so parsing starts after the last ')'.
+func parseStatPPID(stat string) (uint32, bool) {
+	i := strings.LastIndexByte(stat, ')')
+	if i < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(stat[i+1:])
+	if len(fields) < 2 {
+		return 0, false
+	}
+	ppid, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(ppid), true
+}
@@ -0,0 +1,185 @@
+package focus
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeWindow is a windowProvider returning a fixed PID or error.
+type fakeWindow struct {
+	pid uint32
+	err error
+}
+
+func (w fakeWindow) ActivePID() (uint32, error) { return w.pid, w.err }
+
+// processTable returns a processes func serving a fixed parent map.
+func processTable(parentOf map[uint32]uint32) func() (map[uint32]uint32, error) {
+	return func() (map[uint32]uint32, error) { return parentOf, nil }
+}
+
+// fakeTmux answers display-message and list-clients with canned output.
+func fakeTmux(display, clients string) func(args ...string) (string, error) {
+	return func(args ...string) (string, error) {
+		switch args[0] {
+		case "display-message":
+			return display, nil
+		case "list-clients":
+			return clients, nil
+		}
+		return "", errors.New("unexpected tmux command: " + strings.Join(args, " "))
+	}
+}
+
+func TestDetector_TerminalFocused(t *testing.T) {
+	// hook(100) -> claude(50) -> shell(20) -> terminal(10)
+	d := detector{
+		pid:       100,
+		processes: processTable(map[uint32]uint32{100: 50, 50: 20, 20: 10, 10: 1}),
+		window:    fakeWindow{pid: 10},
+	}
+	if !d.focused() {
+		t.Error("expected focused when active window belongs to an ancestor")
+	}
+}
+
+func TestDetector_OtherWindowFocused(t *testing.T) {
+	d := detector{
+		pid:       100,
+		processes: processTable(map[uint32]uint32{100: 50, 50: 20, 20: 10, 10: 1}),
+		window:    fakeWindow{pid: 999},
+	}
+	if d.focused() {
+		t.Error("expected not focused when active window is unrelated")
+	}
+}
+
+func TestDetector_WindowError(t *testing.T) {
+	d := detector{
+		pid:       100,
+		processes: processTable(map[uint32]uint32{100: 10}),
+		window:    fakeWindow{err: errors.New("xprop not installed")},
+	}
+	if d.focused() {
+		t.Error("expected fail-open (not focused) on window lookup error")
+	}
+}
+
+func TestDetector_TmuxActivePaneFocusedTerminal(t *testing.T) {
+	// hook(100) -> tmux server(30); client(40) -> shell(20) -> terminal(10)
+	d := detector{
+		pid:       100,
+		processes: processTable(map[uint32]uint32{100: 30, 30: 1, 40: 20, 20: 10, 10: 1}),
+		window:    fakeWindow{pid: 10},
+		tmuxPane:  "%3",
+		tmux:      fakeTmux("1 1 1 $0\n", "40\n"),
+	}
+	if !d.focused() {
+		t.Error("expected focused when the tmux client's terminal is active")
+	}
+}
+
+func TestDetector_TmuxInactivePane(t *testing.T) {
+	d := detector{
+		pid:       100,
+		processes: processTable(map[uint32]uint32{100: 30, 40: 20, 20: 10}),
+		window:    fakeWindow{pid: 10},
+		tmuxPane:  "%3",
+		tmux:      fakeTmux("0 1 1 $0\n", "40\n"),
+	}
+	if d.focused() {
+		t.Error("expected not focused when our pane is not the active one")
+	}
+}
+
+func TestDetector_TmuxDetached(t *testing.T) {
+	d := detector{
+		pid:      100,
+		window:   fakeWindow{pid: 10},
+		tmuxPane: "%3",
+		tmux:     fakeTmux("1 1 0 $0\n", ""),
+	}
+	if d.focused() {
+		t.Error("expected not focused when no client is attached")
+	}
+}
+
+func TestDetector_TmuxWithoutDisplay(t *testing.T) {
+	d := detector{
+		pid:      100,
+		window:   fakeWindow{err: errNoDisplay},
+		tmuxPane: "%3",
+		tmux:     fakeTmux("1 1 1 $0\n", "40\n"),
+	}
+	if !d.focused() {
+		t.Error("expected active attached pane to count as focused without a display")
+	}
+}
+
+func TestParseActiveWindow(t *testing.T) {
+	id, err := parseActiveWindow("_NET_ACTIVE_WINDOW(WINDOW): window id # 0x3a00007\n")
+	if err != nil || id != "0x3a00007" {
+		t.Errorf("got %q, %v", id, err)
+	}
+	if _, err := parseActiveWindow("_NET_ACTIVE_WINDOW(WINDOW): window id # 0x0\n"); err == nil {
+		t.Error("expected error for no active window")
+	}
+}
+
+func TestParseWindowPID(t *testing.T) {
+	pid, err := parseWindowPID("_NET_WM_PID(CARDINAL) = 12345\n")
+	if err != nil || pid != 12345 {
+		t.Errorf("got %d, %v", pid, err)
+	}
+	if _, err := parseWindowPID("_NET_WM_PID:  not found.\n"); err == nil {
+		t.Error("expected error when window has no PID")
+	}
+}
+
+func TestParseStatPPID(t *testing.T) {
+	ppid, ok := parseStatPPID("1234 (weird ) name) S 567 1234 1234 0 -1")
+	if !ok || ppid != 567 {
+		t.Errorf("got %d, %v", ppid, ok)
+	}
+	if _, ok := parseStatPPID("garbage"); ok {
+		t.Error("expected failure on malformed stat")
+	}
+}
+
+func TestBuildProcessMap_FakeProc(t *testing.T) {
+	root := t.TempDir()
+	for pid, stat := range map[string]string{
+		"1":    "1 (init) S 0 1 1",
+		"42":   "42 (bash) S 1 42 42",
+		"self": "42 (bash) S 1 42 42",
+	} {
+		os.MkdirAll(filepath.Join(root, pid), 0755)
+		os.WriteFile(filepath.Join(root, pid, "stat"), []byte(stat), 0644)
+	}
+
+	parentOf, err := buildProcessMap(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parentOf) != 2 || parentOf[42] != 1 || parentOf[1] != 0 {
+		t.Errorf("unexpected process map: %v", parentOf)
+	}
+}
+
+func TestBuildProcessMap_RealProc(t *testing.T) {
+	parentOf, err := buildProcessMap("/proc")
+	if err != nil {
+		t.Skipf("no /proc: %v", err)
+	}
+	if _, ok := parentOf[uint32(os.Getpid())]; !ok {
+		t.Fatalf("our PID %d not found in process map", os.Getpid())
+	}
+}
+
+func TestTerminalIsFocused_DoesNotPanic(t *testing.T) {
+	// Smoke test — just make sure it doesn't panic.
+	_ = TerminalIsFocused()
+}
@@ -1,9 +1,9 @@
-//go:build !windows
+//go:build !windows && !linux
 
 package focus
 
-// TerminalIsFocused always returns false on non-Windows platforms,
-// meaning notifications are always shown.
+// TerminalIsFocused always returns false on platforms without a focus
+// implementation, meaning notifications are always shown.
 func TerminalIsFocused() bool {
 	return false
 }
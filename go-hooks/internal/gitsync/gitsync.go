@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/valentinclaes/claude-hooks/internal/config"
@@ -13,6 +14,7 @@ import (
 
 const lockTimeout = 5 * time.Minute
 const syncTimeout = 30 * time.Second
+const branchTimeout = 2 * time.Second
 
 // SyncIfEnabled commits and pushes vault changes if git_auto_push is enabled in config.
 // All errors are swallowed silently (matching project convention).
@@ -55,6 +57,18 @@ func SyncIfEnabled(vaultDir string) {
 	_ = gitCmd(ctx, gitRoot, "push")
 }
 
+// Branch returns the current branch of the repository containing dir, or ""
+// if there is none (not a repo, detached HEAD, git not installed).
+func Branch(dir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), branchTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "branch", "--show-current").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
 // findGitRoot walks up from dir looking for a .git directory.
 // Returns the git root path, or "" if not found.
 func findGitRoot(dir string) string {
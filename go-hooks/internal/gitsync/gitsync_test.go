@@ -17,6 +17,7 @@ func setConfigHome(t *testing.T, json string) {
 	os.WriteFile(filepath.Join(hooksDir, "config.json"), []byte(json), 0644)
 	t.Setenv("USERPROFILE", dir) // os.UserHomeDir() reads this on Windows
 	t.Setenv("HOME", dir)        // os.UserHomeDir() reads this on Unix
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
 }
 
 func TestFindGitRoot(t *testing.T) {
@@ -166,6 +167,18 @@ func TestSyncIfEnabled_NothingToCommit(t *testing.T) {
 	}
 }
 
+func TestBranch(t *testing.T) {
+	_, clone := initBareAndClone(t)
+	run(t, clone, "git", "checkout", "-b", "feature/x")
+
+	if got := Branch(clone); got != "feature/x" {
+		t.Errorf("expected feature/x, got %q", got)
+	}
+	if got := Branch(t.TempDir()); got != "" {
+		t.Errorf("expected empty branch outside a repo, got %q", got)
+	}
+}
+
 func TestAcquireLock_Exclusive(t *testing.T) {
 	dir := t.TempDir()
 	lockPath := filepath.Join(dir, "test.lock")
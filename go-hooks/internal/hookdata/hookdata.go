@@ -19,6 +19,63 @@ type StopInput struct {
 	TranscriptPath string `json:"transcript_path"`
 }
 
+// SessionStartInput is the JSON sent to SessionStart hooks. Source is
+// "startup", "resume", "clear" or "compact".
+type SessionStartInput struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Cwd            string `json:"cwd"`
+	Source         string `json:"source"`
+}
+
+// SessionEndInput is the JSON sent to SessionEnd hooks. Reason is "clear",
+// "logout", "prompt_input_exit" or "other".
+type SessionEndInput struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Cwd            string `json:"cwd"`
+	Reason         string `json:"reason"`
+}
+
+// CompactInput is the JSON sent to PreCompact hooks. Trigger is "manual"
+// (/compact, with optional CustomInstructions) or "auto".
+type CompactInput struct {
+	SessionID          string `json:"session_id"`
+	TranscriptPath     string `json:"transcript_path"`
+	Trigger            string `json:"trigger"`
+	CustomInstructions string `json:"custom_instructions"`
+}
+
+// SubagentStopInput is the JSON sent to SubagentStop hooks. Newer Claude Code
+// versions write each sub-agent to its own AgentTranscriptPath; older ones
+// keep its sidechain entries in the session transcript.
+type SubagentStopInput struct {
+	SessionID           string `json:"session_id"`
+	TranscriptPath      string `json:"transcript_path"`
+	AgentID             string `json:"agent_id"`
+	AgentTranscriptPath string `json:"agent_transcript_path"`
+}
+
+// NotificationInput is the JSON sent to Notification hooks: permission
+// requests and idle warnings. NotificationType is only set by newer Claude
+// Code versions.
+type NotificationInput struct {
+	SessionID        string `json:"session_id"`
+	Message          string `json:"message"`
+	Title            string `json:"title"`
+	NotificationType string `json:"notification_type"`
+}
+
+// ToolInput is the JSON sent to PreToolUse and PostToolUse hooks.
+// ToolResponse is only present for PostToolUse.
+type ToolInput struct {
+	SessionID     string          `json:"session_id"`
+	HookEventName string          `json:"hook_event_name"`
+	ToolName      string          `json:"tool_name"`
+	ToolInput     json.RawMessage `json:"tool_input"`
+	ToolResponse  json.RawMessage `json:"tool_response"`
+}
+
 // ReadStdin reads all of stdin and JSON-decodes it into target.
 func ReadStdin(target any) error {
 	data, err := io.ReadAll(os.Stdin)
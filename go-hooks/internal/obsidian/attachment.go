@@ -0,0 +1,83 @@
+package obsidian
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/valentinclaes/claude-hooks/internal/config"
+)
+
+// attachmentsDir is the folder, inside a project folder, holding the full
+// text of entries too long for their callout.
+const attachmentsDir = "attachments"
+
+// Limiter fits entry text into the limits configured for a session note.
+type Limiter struct {
+	Limits   config.Limits
+	VaultDir string
+	NotePath string
+}
+
+// Fit returns text shortened to maxLen with truncate. In attachment mode the
+// full text is instead written to an attachment next to the note and the
+// returned text links to it: an embed replaces the text entirely, a plain link
+// follows the truncated preview. A maxLen <= 0 means no limit.
+func (l Limiter) Fit(text string, maxLen int, truncate func(string, int) string) string {
+	n := utf8.RuneCountInString(text)
+	if maxLen <= 0 || n <= maxLen {
+		return text
+	}
+	if l.Limits.Overflow != config.OverflowAttachment {
+		return truncate(text, maxLen)
+	}
+	link, err := writeAttachment(l.VaultDir, l.NotePath, text)
+	if err != nil {
+		return truncate(text, maxLen) // keep the preview rather than lose the entry
+	}
+	if l.Limits.Embed {
+		return "![[" + link + "]]"
+	}
+	return cutText(text, maxLen) + fmt.Sprintf("\n\n... (truncated, %d chars total, full text in [[%s]])", n, link)
+}
+
+// writeAttachment writes text to {project}/attachments/{note}_{n}.md, using
+// the first free n, and returns its vault-relative link target.
+func writeAttachment(vaultDir, notePath, text string) (string, error) {
+	dir := filepath.Join(filepath.Dir(notePath), attachmentsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	noteName := strings.TrimSuffix(filepath.Base(notePath), ".md")
+	noteLink := noteName
+	if rel, err := filepath.Rel(vaultDir, notePath); err == nil {
+		noteLink = strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+	}
+	content := "---\nsession_note: \"[[" + noteLink + "]]\"\ntags:\n  - claude-attachment\n---\n" + text + "\n"
+
+	for n := 1; ; n++ {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%d.md", noteName, n))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.WriteString(content)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		rel, err := filepath.Rel(vaultDir, path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(filepath.ToSlash(rel), ".md"), nil
+	}
+}
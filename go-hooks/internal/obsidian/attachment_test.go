@@ -0,0 +1,73 @@
+package obsidian
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/valentinclaes/claude-hooks/internal/config"
+)
+
+func newLimiter(t *testing.T, overflow string, embed bool) (Limiter, string) {
+	t.Helper()
+	vault := t.TempDir()
+	note := filepath.Join(vault, "Coding", "2026-02-13_1350.md")
+	os.MkdirAll(filepath.Dir(note), 0755)
+	return Limiter{
+		Limits:   config.Limits{Overflow: overflow, Embed: embed},
+		VaultDir: vault,
+		NotePath: note,
+	}, vault
+}
+
+// TestLimiter_Truncate verifies the default mode behaves like the truncate func.
+func TestLimiter_Truncate(t *testing.T) {
+	l, vault := newLimiter(t, config.OverflowTruncate, true)
+	long := strings.Repeat("x", 20)
+	if got, want := l.Fit(long, 10, Truncate), Truncate(long, 10); got != want {
+		t.Errorf("Fit: got %q, want %q", got, want)
+	}
+	if got := l.Fit("short", 10, Truncate); got != "short" {
+		t.Errorf("Fit short: got %q", got)
+	}
+	if got := l.Fit(long, 0, Truncate); got != long {
+		t.Errorf("Fit with no limit: got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(vault, "Coding", attachmentsDir)); !os.IsNotExist(err) {
+		t.Error("truncate mode should not write attachments")
+	}
+}
+
+// TestLimiter_AttachmentEmbed verifies the full text moves to a numbered
+// attachment that the callout embeds.
+func TestLimiter_AttachmentEmbed(t *testing.T) {
+	l, vault := newLimiter(t, config.OverflowAttachment, true)
+	long := strings.Repeat("x", 20)
+
+	if got, want := l.Fit(long, 10, Truncate), "![[Coding/attachments/2026-02-13_1350_1]]"; got != want {
+		t.Errorf("first Fit: got %q, want %q", got, want)
+	}
+	if got, want := l.Fit(long, 10, Truncate), "![[Coding/attachments/2026-02-13_1350_2]]"; got != want {
+		t.Errorf("second Fit: got %q, want %q", got, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(vault, "Coding", "attachments", "2026-02-13_1350_1.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if !strings.Contains(content, `session_note: "[[Coding/2026-02-13_1350]]"`) || !strings.HasSuffix(content, "---\n"+long+"\n") {
+		t.Errorf("unexpected attachment content:\n%s", content)
+	}
+}
+
+// TestLimiter_AttachmentLink verifies link mode keeps a preview before the link.
+func TestLimiter_AttachmentLink(t *testing.T) {
+	l, _ := newLimiter(t, config.OverflowAttachment, false)
+	got := l.Fit(strings.Repeat("x", 20), 10, Truncate)
+	want := strings.Repeat("x", 10) + "\n\n... (truncated, 20 chars total, full text in [[Coding/attachments/2026-02-13_1350_1]])"
+	if got != want {
+		t.Errorf("Fit: got %q, want %q", got, want)
+	}
+}
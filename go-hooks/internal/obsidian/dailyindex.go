@@ -6,106 +6,69 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/valentinclaes/claude-hooks/internal/atomicfile"
+	"github.com/valentinclaes/claude-hooks/internal/session"
 )
 
 var (
 	durationRe  = regexp.MustCompile(`(?m)^duration:\s*(.+)$`)
+	activeRe    = regexp.MustCompile(`(?m)^active_duration:\s*(.+)$`)
 	sessionIDRe = regexp.MustCompile(`(?m)^session_id:\s*(.+)$`)
 	userCallout = regexp.MustCompile(`\[!user\]`)
+	// resumedFromRe matches the frontmatter link to the parent session.
+	resumedFromRe = regexp.MustCompile(`(?m)^resumed_from:\s*"?\[\[([^\]|]+)`)
 )
 
 type sessionEntry struct {
+	Date     string
 	Project  string
 	RelPath  string
 	Time     string
 	Duration string
-	Prompts  int
+	// Active is the active_duration from frontmatter, or "".
+	Active  string
+	Prompts int
+	Usage   Usage
+	// ResumedFrom is the vault path of the parent session note, or "".
+	ResumedFrom string
+	// Excerpt is the start of the first prompt.
+	Excerpt string
+	// Title is the session title from frontmatter, or "".
+	Title string
+	// End is when the session was last active (start plus duration), or zero.
+	End time.Time
+	// Continued is set when the session is listed under a day after its start.
+	Continued bool
+	// Running is set while the session is open: its note says so and its
+	// state still exists.
+	Running bool
 }
 
+// continuedLookback is how many days before a date are searched for
+// sessions still running on it.
+const continuedLookback = 7
+
 // RebuildDailyIndex scans project subdirs for today's sessions and rebuilds the daily index.
 func RebuildDailyIndex(vaultDir, date string) error {
-	entries, err := os.ReadDir(vaultDir)
+	sessions, err := collectSessions(vaultDir, date+"_*.md")
 	if err != nil {
 		return err
 	}
 
-	var sessions []sessionEntry
-
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-		projectDir := filepath.Join(vaultDir, entry.Name())
-		pattern := filepath.Join(projectDir, date+"_*.md")
-		matches, err := filepath.Glob(pattern)
-		if err != nil {
-			continue
-		}
-		for _, match := range matches {
-			content, err := os.ReadFile(match)
-			if err != nil {
-				continue
-			}
-			contentStr := string(content)
-			fileName := filepath.Base(match)
-
-			// Extract time from filename (e.g., 2026-02-12_0915.md -> 09:15)
-			timeStr := ""
-			prefix := date + "_"
-			after := strings.TrimPrefix(fileName, prefix)
-			if len(after) >= 4 {
-				digits := after[:4]
-				if isDigits(digits) {
-					timeStr = digits[:2] + ":" + digits[2:4]
-				}
-			}
-
-			// Extract duration from frontmatter
-			duration := ""
-			if m := durationRe.FindStringSubmatch(contentStr); len(m) > 1 {
-				duration = strings.TrimSpace(m[1])
-			}
-
-			// Extract prompt count: try session temp file, fallback to counting callouts
-			prompts := 0
-			if m := sessionIDRe.FindStringSubmatch(contentStr); len(m) > 1 {
-				sid := strings.TrimSpace(m[1])
-				mapFile := filepath.Join(os.TempDir(), "claude_session_"+sid+".txt")
-				if data, err := os.ReadFile(mapFile); err == nil {
-					lines := strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)
-					if len(lines) >= 2 {
-						fmt.Sscanf(strings.TrimSpace(lines[1]), "%d", &prompts)
-					}
-				}
-			}
-			if prompts == 0 {
-				prompts = len(userCallout.FindAllString(contentStr, -1))
-			}
-
-			rel, err := filepath.Rel(vaultDir, match)
-			if err != nil {
-				continue
-			}
-			relPath := strings.ReplaceAll(rel, "\\", "/")
-			relPath = strings.TrimSuffix(relPath, ".md")
-
-			sessions = append(sessions, sessionEntry{
-				Project:  entry.Name(),
-				RelPath:  relPath,
-				Time:     timeStr,
-				Duration: duration,
-				Prompts:  prompts,
-			})
-		}
-	}
-
+	sessions = append(sessions, continuedSessions(vaultDir, date)...)
 	if len(sessions) == 0 {
 		return nil
 	}
 
-	// Sort by time, then group by project
+	// Sort continued sessions first (they began earlier), then by time; group by project
 	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].Continued != sessions[j].Continued {
+			return sessions[i].Continued
+		}
 		return sessions[i].Time < sessions[j].Time
 	})
 
@@ -121,29 +84,236 @@ func RebuildDailyIndex(vaultDir, date string) error {
 		return strings.ToLower(projectOrder[i]) < strings.ToLower(projectOrder[j])
 	})
 
+	// Usage counts towards the day a session started, so continued sessions
+	// are left out of the totals
+	var dayUsage Usage
+	activeMin := 0
+	for _, s := range sessions {
+		if !s.Continued {
+			dayUsage = sumUsage(dayUsage, s.Usage)
+			activeMin += durationMinutes(s.Active)
+		}
+	}
+
 	var sb strings.Builder
-	sb.WriteString("---\ndate: " + date + "\ntags:\n  - claude-daily\n---\n\n# Claude Sessions - " + date + "\n")
+	sb.WriteString("---\ndate: " + date + "\n")
+	if activeMin > 0 {
+		sb.WriteString(fmt.Sprintf("active_min: %d\n", activeMin))
+	}
+	if !dayUsage.IsZero() {
+		sb.WriteString(fmt.Sprintf("input_tokens: %d\noutput_tokens: %d\ncost_usd: %.4f\n",
+			dayUsage.InputTokens, dayUsage.OutputTokens, dayUsage.CostUSD))
+	}
+	sb.WriteString("tags:\n  - claude-daily\n---\n\n# Claude Sessions - " + date + "\n")
+	if activeMin > 0 {
+		sb.WriteString("\n*Active time: " + formatMinutes(activeMin) + "*\n")
+	}
+	if !dayUsage.IsZero() {
+		sb.WriteString("\n" + formatUsageTotal(dayUsage) + "\n")
+	}
 
 	for _, proj := range projectOrder {
 		sb.WriteString("\n## " + proj + "\n")
+		var projUsage Usage
 		for _, s := range grouped[proj] {
-			var parts []string
-			if s.Duration != "" {
-				parts = append(parts, s.Duration)
+			line := "- [[" + s.RelPath + "|" + s.alias() + "]]" + s.meta()
+			if s.Continued {
+				sb.WriteString(line + " - continued from [[" + s.Date + "]]\n")
+				continue
+			}
+			sb.WriteString(line + "\n")
+			projUsage = sumUsage(projUsage, s.Usage)
+		}
+		if !projUsage.IsZero() {
+			sb.WriteString("\n" + formatUsageTotal(projUsage) + "\n")
+		}
+	}
+
+	dailyPath := filepath.Join(vaultDir, date+".md")
+	return atomicfile.WriteFile(dailyPath, []byte(sb.String()), 0644)
+}
+
+// continuedSessions returns the sessions started in the continuedLookback
+// days before date that were last active on date, marked as continued.
+func continuedSessions(vaultDir, date string) []sessionEntry {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil
+	}
+	var continued []sessionEntry
+	for i := 1; i <= continuedLookback; i++ {
+		found, _ := collectSessions(vaultDir, day.AddDate(0, 0, -i).Format("2006-01-02")+"_*.md")
+		for _, s := range found {
+			if !s.End.IsZero() && s.End.Format("2006-01-02") == date {
+				s.Continued = true
+				continued = append(continued, s)
 			}
-			if s.Prompts > 0 {
-				parts = append(parts, fmt.Sprintf("%d prompts", s.Prompts))
+		}
+	}
+	return continued
+}
+
+// alias renders the link text of an index entry: start time and title.
+func (s sessionEntry) alias() string {
+	if s.Title == "" {
+		return s.Time
+	}
+	return s.Time + " " + s.Title
+}
+
+// meta renders the " (10min, 4 prompts, $0.25)" suffix of an index entry.
+func (s sessionEntry) meta() string {
+	var parts []string
+	if s.Running {
+		parts = append(parts, "running")
+	}
+	if s.Duration != "" {
+		parts = append(parts, s.Duration)
+	}
+	if s.Active != "" {
+		parts = append(parts, s.Active+" active")
+	}
+	if s.Prompts > 0 {
+		parts = append(parts, fmt.Sprintf("%d prompts", s.Prompts))
+	}
+	if s.Usage.CostUSD > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f", s.Usage.CostUSD))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// collectSessions reads the session notes in the project subdirs whose file
+// name matches namePattern (e.g. "2026-02-12_*.md" or "2026-02-*.md").
+func collectSessions(vaultDir, namePattern string) ([]sessionEntry, error) {
+	entries, err := os.ReadDir(vaultDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []sessionEntry
+	for _, entry := range entries {
+		if entry.IsDir() {
+			sessions = append(sessions, collectProjectSessions(vaultDir, entry.Name(), namePattern)...)
+		}
+	}
+	return sessions, nil
+}
+
+// collectProjectSessions reads the session notes in one project subdir whose
+// file name matches namePattern.
+func collectProjectSessions(vaultDir, project, namePattern string) []sessionEntry {
+	var sessions []sessionEntry
+	matches, err := filepath.Glob(filepath.Join(vaultDir, project, namePattern))
+	if err != nil {
+		return nil
+	}
+	for _, match := range matches {
+		fileName := filepath.Base(match)
+
+		// File names start with the session date (e.g., 2026-02-12_0915.md)
+		if len(fileName) < len("2006-01-02_") || fileName[10] != '_' {
+			continue
+		}
+		date := fileName[:10]
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			continue
+		}
+
+		content, err := os.ReadFile(match)
+		if err != nil {
+			continue
+		}
+		contentStr := string(content)
+
+		// Extract time from filename (e.g., 2026-02-12_0915.md -> 09:15)
+		timeStr := ""
+		after := fileName[len(date)+1:]
+		if len(after) >= 4 {
+			digits := after[:4]
+			if isDigits(digits) {
+				timeStr = digits[:2] + ":" + digits[2:4]
 			}
-			meta := ""
-			if len(parts) > 0 {
-				meta = " (" + strings.Join(parts, ", ") + ")"
+		}
+
+		// Extract duration from frontmatter
+		duration := ""
+		if m := durationRe.FindStringSubmatch(contentStr); len(m) > 1 {
+			duration = strings.TrimSpace(m[1])
+		}
+
+		// Extract prompt count: try session state, then the count written when
+		// the session ended, fallback to counting callouts
+		prompts := 0
+		running := false
+		if m := sessionIDRe.FindStringSubmatch(contentStr); len(m) > 1 {
+			if sd, _ := session.Read(strings.TrimSpace(m[1])); sd != nil {
+				prompts = sd.PromptNum
+				running = FrontmatterField(contentStr, "status") == StatusRunning
 			}
-			sb.WriteString("- [[" + s.RelPath + "|" + s.Time + "]]" + meta + "\n")
 		}
+		if prompts == 0 {
+			prompts, _ = strconv.Atoi(FrontmatterField(contentStr, "prompts"))
+		}
+		if prompts == 0 {
+			prompts = len(userCallout.FindAllString(contentStr, -1))
+		}
+
+		rel, err := filepath.Rel(vaultDir, match)
+		if err != nil {
+			continue
+		}
+		resumedFrom := ""
+		if m := resumedFromRe.FindStringSubmatch(contentStr); len(m) > 1 {
+			resumedFrom = m[1]
+		}
+		relPath := strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+
+		active := ""
+		if m := activeRe.FindStringSubmatch(contentStr); len(m) > 1 {
+			active = strings.TrimSpace(m[1])
+		}
+
+		var end time.Time
+		if start, ok := NoteStart(contentStr); ok {
+			end = start.Local().Add(time.Duration(durationMinutes(duration)) * time.Minute)
+		}
+
+		sessions = append(sessions, sessionEntry{
+			Date:        date,
+			Project:     project,
+			RelPath:     relPath,
+			Time:        timeStr,
+			Duration:    duration,
+			Active:      active,
+			Prompts:     prompts,
+			Usage:       ReadUsage(contentStr),
+			ResumedFrom: resumedFrom,
+			Excerpt:     firstPromptExcerpt(contentStr),
+			Title:       NoteTitle(contentStr),
+			End:         end,
+			Running:     running,
+		})
 	}
+	return sessions
+}
 
-	dailyPath := filepath.Join(vaultDir, date+".md")
-	return os.WriteFile(dailyPath, []byte(sb.String()), 0644)
+// sumUsage adds the token counts and cost of b to a.
+func sumUsage(a, b Usage) Usage {
+	a.InputTokens += b.InputTokens
+	a.OutputTokens += b.OutputTokens
+	a.CacheReadTokens += b.CacheReadTokens
+	a.CacheCreationTokens += b.CacheCreationTokens
+	a.CostUSD += b.CostUSD
+	return a
+}
+
+// formatUsageTotal renders a usage total line for index notes.
+func formatUsageTotal(u Usage) string {
+	return fmt.Sprintf("*Tokens: %s in / %s out, %s cache read - ~$%.2f*",
+		FormatTokens(u.InputTokens), FormatTokens(u.OutputTokens), FormatTokens(u.CacheReadTokens), u.CostUSD)
 }
 
 func isDigits(s string) bool {
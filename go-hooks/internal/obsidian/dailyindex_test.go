@@ -5,8 +5,20 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/valentinclaes/claude-hooks/internal/session"
 )
 
+// setHome points the user home (and with it the session state dir) at a temp dir.
+func setHome(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("USERPROFILE", dir) // os.UserHomeDir() reads this on Windows
+	t.Setenv("HOME", dir)        // os.UserHomeDir() reads this on Unix
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+}
+
 // TestRebuildDailyIndex_SortOrder verifies case-insensitive project sorting matches PS.
 func TestRebuildDailyIndex_SortOrder(t *testing.T) {
 	// Create temp vault with project dirs matching the real vault
@@ -67,10 +79,9 @@ func TestRebuildDailyIndex_Format(t *testing.T) {
 	sessionContent := "---\ndate: " + date + "\nsession_id: test-id\nproject: Coding\nstart_time: 17:42\nduration: 10min\ntags:\n  - claude-session\n---\n\n# Claude Session\n\n---\n\n> [!user]+ #1 - You (17:42:00)\n> test\n\n---\n"
 	os.WriteFile(filepath.Join(dir, date+"_1742.md"), []byte(sessionContent), 0644)
 
-	// Write a temp session file so prompt count is found
-	mapFile := filepath.Join(os.TempDir(), "claude_session_test-id.txt")
-	os.WriteFile(mapFile, []byte(filepath.Join(dir, date+"_1742.md")+"\n4"), 0644)
-	defer os.Remove(mapFile)
+	// Write session state so prompt count is found
+	setHome(t)
+	session.Write("test-id", &session.SessionData{FilePath: filepath.Join(dir, date+"_1742.md"), PromptNum: 4})
 
 	err := RebuildDailyIndex(tmpDir, date)
 	if err != nil {
@@ -92,3 +103,148 @@ func TestRebuildDailyIndex_Format(t *testing.T) {
 		t.Errorf("Daily index format mismatch\ngot:\n%s\nwant:\n%s", got, want)
 	}
 }
+
+// TestRebuildDailyIndex_UsageTotals verifies token and cost totals roll up per day and project.
+func TestRebuildDailyIndex_UsageTotals(t *testing.T) {
+	tmpDir := t.TempDir()
+	date := "2026-02-12"
+
+	dir := filepath.Join(tmpDir, "Coding")
+	os.MkdirAll(dir, 0755)
+	for i, name := range []string{date + "_0900.md", date + "_1000.md"} {
+		content := "---\ndate: " + date + "\nsession_id: usage-" + name + "\nproject: Coding\nstart_time: 09:00\n" +
+			"input_tokens: 1000\noutput_tokens: 500\ncache_read_tokens: 0\ncost_usd: 0.2500\ntags:\n  - claude-session\n---\n"
+		if i == 1 {
+			content = strings.Replace(content, "cost_usd: 0.2500", "cost_usd: 0.5000", 1)
+		}
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+
+	if err := RebuildDailyIndex(tmpDir, date); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, date+".md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+
+	for _, want := range []string{
+		"input_tokens: 2000\noutput_tokens: 1000\ncost_usd: 0.7500\ntags:",
+		"- [[Coding/2026-02-12_0900|09:00]] ($0.25)\n",
+		"*Tokens: 2.0k in / 1.0k out, 0 cache read - ~$0.75*",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("daily index missing %q\ngot:\n%s", want, got)
+		}
+	}
+}
+
+// TestRebuildDailyIndex_CrossMidnight verifies a session that ran past
+// midnight is listed under both days, continued on the second and left out
+// of its totals.
+func TestRebuildDailyIndex_CrossMidnight(t *testing.T) {
+	setHome(t)
+	vault := t.TempDir()
+	dir := filepath.Join(vault, "Coding")
+	os.MkdirAll(dir, 0755)
+	late := "---\ndate: 2026-02-11\nsession_id: late\nproject: Coding\nstart_time: 23:30\n" +
+		"started_at: 2026-02-11T23:30:00+01:00\nduration: 50min\ncost_usd: 0.5000\ntags:\n  - claude-session\n---\n"
+	os.WriteFile(filepath.Join(dir, "2026-02-11_2330.md"), []byte(late), 0644)
+	writeSessionNote(t, vault, "Coding", "2026-02-12_0900.md", "10min", "0.2500")
+
+	local := time.Local
+	time.Local = time.FixedZone("CET", 3600)
+	t.Cleanup(func() { time.Local = local })
+
+	for _, date := range []string{"2026-02-11", "2026-02-12"} {
+		if err := RebuildDailyIndex(vault, date); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, _ := os.ReadFile(filepath.Join(vault, "2026-02-11.md"))
+	if !strings.Contains(string(first), "- [[Coding/2026-02-11_2330|23:30]] (50min, $0.50)\n") {
+		t.Errorf("start day should list the session, got:\n%s", first)
+	}
+	second, _ := os.ReadFile(filepath.Join(vault, "2026-02-12.md"))
+	got := string(second)
+	want := "## Coding\n" +
+		"- [[Coding/2026-02-11_2330|23:30]] (50min, $0.50) - continued from [[2026-02-11]]\n" +
+		"- [[Coding/2026-02-12_0900|09:00]] (10min, 1 prompts, $0.25)\n"
+	if !strings.Contains(got, want) || !strings.Contains(got, "cost_usd: 0.2500\n") {
+		t.Errorf("second day should list the continued session first, without its cost; got:\n%s", got)
+	}
+}
+
+// TestNoteStart verifies started_at is preferred over date and start_time.
+func TestNoteStart(t *testing.T) {
+	got, ok := NoteStart("---\ndate: 2026-02-11\nstart_time: 23:30\nstarted_at: 2026-02-11T23:30:15Z\n---\n")
+	if !ok || !got.Equal(time.Date(2026, 2, 11, 23, 30, 15, 0, time.UTC)) {
+		t.Errorf("started_at: got %v, %v", got, ok)
+	}
+	got, ok = NoteStart("---\ndate: 2026-02-11\nstart_time: 23:30\n---\n")
+	if !ok || got.Format("2006-01-02 15:04") != "2026-02-11 23:30" {
+		t.Errorf("legacy fields: got %v, %v", got, ok)
+	}
+	if _, ok := NoteStart("no frontmatter"); ok {
+		t.Error("expected no start without frontmatter")
+	}
+}
+
+// TestRebuildDailyIndex_ActiveTime verifies active durations are shown per
+// session and summed for the day.
+func TestRebuildDailyIndex_ActiveTime(t *testing.T) {
+	setHome(t)
+	vault := t.TempDir()
+	writeSessionNote(t, vault, "Coding", "2026-02-12_0900.md", "4h 10min", "0.2500")
+	writeSessionNote(t, vault, "api", "2026-02-12_1400.md", "30min", "0.2500")
+	for name, active := range map[string]string{"Coding/2026-02-12_0900.md": "50min", "api/2026-02-12_1400.md": "25min"} {
+		path := filepath.Join(vault, name)
+		data, _ := os.ReadFile(path)
+		os.WriteFile(path, []byte(SetFrontmatterField(string(data), "active_duration", active)), 0644)
+	}
+
+	if err := RebuildDailyIndex(vault, "2026-02-12"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(vault, "2026-02-12.md"))
+	got := string(data)
+	for _, want := range []string{
+		"date: 2026-02-12\nactive_min: 75\n",
+		"\n*Active time: 1h 15min*\n",
+		"- [[Coding/2026-02-12_0900|09:00]] (4h 10min, 50min active, 1 prompts, $0.25)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("daily index missing %q\ngot:\n%s", want, got)
+		}
+	}
+}
+
+// TestRebuildDailyIndex_Running verifies open sessions are marked running,
+// but only while their state exists.
+func TestRebuildDailyIndex_Running(t *testing.T) {
+	setHome(t)
+	vault := t.TempDir()
+	for _, name := range []string{"2026-02-12_0900.md", "2026-02-12_1000.md"} {
+		writeSessionNote(t, vault, "Coding", name, "10min", "0.2500")
+		path := filepath.Join(vault, "Coding", name)
+		data, _ := os.ReadFile(path)
+		os.WriteFile(path, []byte(SetFrontmatterField(string(data), "status", StatusRunning)), 0644)
+	}
+	// Only the 10:00 session is still open; the other lost its state (crashed)
+	session.Write("Coding-2026-02-12_1000.md", &session.SessionData{PromptNum: 2})
+
+	if err := RebuildDailyIndex(vault, "2026-02-12"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(vault, "2026-02-12.md"))
+	got := string(data)
+	for _, want := range []string{
+		"- [[Coding/2026-02-12_0900|09:00]] (10min, 1 prompts, $0.25)\n",
+		"- [[Coding/2026-02-12_1000|10:00]] (running, 10min, 2 prompts, $0.25)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("daily index missing %q\ngot:\n%s", want, got)
+		}
+	}
+}
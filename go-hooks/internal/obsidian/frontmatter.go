@@ -0,0 +1,167 @@
+package obsidian
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valentinclaes/claude-hooks/internal/config"
+	"github.com/valentinclaes/claude-hooks/internal/transcript"
+)
+
+// frontmatterEnd returns the index just past the closing "---\n" of the
+// note's YAML frontmatter, or -1 if the note has none.
+func frontmatterEnd(content string) int {
+	if !strings.HasPrefix(content, "---\n") {
+		return -1
+	}
+	i := strings.Index(content[4:], "\n---\n")
+	if i < 0 {
+		return -1
+	}
+	return 4 + i + len("\n---\n")
+}
+
+func fieldRe(key string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key) + `:[ \t]*(.*)$`)
+}
+
+// FrontmatterField returns the value of a top-level frontmatter key, or "".
+func FrontmatterField(content, key string) string {
+	end := frontmatterEnd(content)
+	if end < 0 {
+		return ""
+	}
+	if m := fieldRe(key).FindStringSubmatch(content[:end]); len(m) > 1 {
+		return strings.TrimSpace(m[1])
+	}
+	return ""
+}
+
+// NoteStart returns when a note's session started: its started_at timestamp
+// or, for notes written before that field existed, its date and start_time
+// in local time.
+func NoteStart(content string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, FrontmatterField(content, "started_at")); err == nil {
+		return t, true
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04",
+		FrontmatterField(content, "date")+" "+FrontmatterField(content, "start_time"), time.Local)
+	return t, err == nil
+}
+
+// SetFrontmatterField sets a top-level frontmatter key, replacing its line if
+// present or otherwise inserting it before the tags list. Content without
+// frontmatter is returned unchanged.
+func SetFrontmatterField(content, key, value string) string {
+	end := frontmatterEnd(content)
+	if end < 0 {
+		return content
+	}
+	fm, body := content[:end], content[end:]
+	line := key + ": " + value
+
+	re := fieldRe(key)
+	if re.MatchString(fm) {
+		fm = re.ReplaceAllLiteralString(fm, line)
+		return fm + body
+	}
+	if i := strings.Index(fm, "\ntags:"); i >= 0 {
+		return fm[:i+1] + line + "\n" + fm[i+1:] + body
+	}
+	closing := len(fm) - len("---\n")
+	return fm[:closing] + line + "\n" + fm[closing:] + body
+}
+
+// RemoveFrontmatterField deletes a top-level frontmatter key and its line.
+func RemoveFrontmatterField(content, key string) string {
+	end := frontmatterEnd(content)
+	if end < 0 {
+		return content
+	}
+	re := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key) + `:.*\n`)
+	return re.ReplaceAllLiteralString(content[:end], "") + content[end:]
+}
+
+// frontmatterInt returns an integer frontmatter field, or 0.
+func frontmatterInt(content, key string) int64 {
+	n, _ := strconv.ParseInt(FrontmatterField(content, key), 10, 64)
+	return n
+}
+
+// frontmatterFloat returns a numeric frontmatter field, or 0.
+func frontmatterFloat(content, key string) float64 {
+	f, _ := strconv.ParseFloat(FrontmatterField(content, key), 64)
+	return f
+}
+
+// Usage is the token usage and estimated cost recorded in a session note.
+type Usage struct {
+	Model               string
+	InputTokens         int64
+	OutputTokens        int64
+	CacheReadTokens     int64
+	CacheCreationTokens int64
+	CostUSD             float64
+}
+
+// IsZero reports whether no usage was recorded.
+func (u Usage) IsZero() bool {
+	return u.InputTokens == 0 && u.OutputTokens == 0 && u.CacheReadTokens == 0 && u.CacheCreationTokens == 0
+}
+
+// UsageFromTranscript sums transcript usage grouped by model, pricing it
+// with cfg's price table. model is recorded as the session's model.
+func UsageFromTranscript(byModel map[string]transcript.Usage, model string, cfg config.Config) Usage {
+	u := Usage{Model: model}
+	for m, t := range byModel {
+		u.InputTokens += t.InputTokens
+		u.OutputTokens += t.OutputTokens
+		u.CacheReadTokens += t.CacheReadInputTokens
+		u.CacheCreationTokens += t.CacheCreationInputTokens
+		if price, ok := cfg.PriceFor(m); ok {
+			u.CostUSD += price.Cost(t.InputTokens, t.OutputTokens, t.CacheReadInputTokens, t.CacheCreationInputTokens)
+		}
+	}
+	return u
+}
+
+// ReadUsage reads the usage fields from a session note's frontmatter.
+func ReadUsage(content string) Usage {
+	return Usage{
+		Model:               FrontmatterField(content, "model"),
+		InputTokens:         frontmatterInt(content, "input_tokens"),
+		OutputTokens:        frontmatterInt(content, "output_tokens"),
+		CacheReadTokens:     frontmatterInt(content, "cache_read_tokens"),
+		CacheCreationTokens: frontmatterInt(content, "cache_creation_tokens"),
+		CostUSD:             frontmatterFloat(content, "cost_usd"),
+	}
+}
+
+// AddUsage adds delta to the usage recorded in the note's frontmatter and
+// returns the updated content. The model field is set if delta names one.
+func AddUsage(content string, delta Usage) string {
+	u := ReadUsage(content)
+	if delta.Model != "" {
+		content = SetFrontmatterField(content, "model", delta.Model)
+	}
+	content = SetFrontmatterField(content, "input_tokens", strconv.FormatInt(u.InputTokens+delta.InputTokens, 10))
+	content = SetFrontmatterField(content, "output_tokens", strconv.FormatInt(u.OutputTokens+delta.OutputTokens, 10))
+	content = SetFrontmatterField(content, "cache_read_tokens", strconv.FormatInt(u.CacheReadTokens+delta.CacheReadTokens, 10))
+	content = SetFrontmatterField(content, "cache_creation_tokens", strconv.FormatInt(u.CacheCreationTokens+delta.CacheCreationTokens, 10))
+	content = SetFrontmatterField(content, "cost_usd", fmt.Sprintf("%.4f", u.CostUSD+delta.CostUSD))
+	return content
+}
+
+// FormatTokens renders a token count compactly (e.g. 950, 12.3k, 4.5M).
+func FormatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return strconv.FormatInt(n, 10)
+}
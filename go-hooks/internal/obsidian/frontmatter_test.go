@@ -0,0 +1,80 @@
+package obsidian
+
+import (
+	"strings"
+	"testing"
+)
+
+const noteWithFrontmatter = "---\n" +
+	"date: 2026-02-13\n" +
+	"session_id: abc\n" +
+	"start_time: 13:50\n" +
+	"tags:\n" +
+	"  - claude-session\n" +
+	"---\n" +
+	"\n# Claude Session - Coding\n" +
+	"\nmodel: not frontmatter\n"
+
+// TestFrontmatterField verifies lookups are confined to the frontmatter.
+func TestFrontmatterField(t *testing.T) {
+	if got := FrontmatterField(noteWithFrontmatter, "start_time"); got != "13:50" {
+		t.Errorf("start_time: got %q", got)
+	}
+	if got := FrontmatterField(noteWithFrontmatter, "model"); got != "" {
+		t.Errorf("model should not be read from the body, got %q", got)
+	}
+	if got := FrontmatterField("no frontmatter", "date"); got != "" {
+		t.Errorf("expected empty for note without frontmatter, got %q", got)
+	}
+}
+
+// TestSetFrontmatterField verifies replace-in-place and insert-before-tags.
+func TestSetFrontmatterField(t *testing.T) {
+	got := SetFrontmatterField(noteWithFrontmatter, "start_time", "14:00")
+	if !strings.Contains(got, "start_time: 14:00\ntags:") {
+		t.Errorf("expected start_time replaced in place, got:\n%s", got)
+	}
+
+	got = SetFrontmatterField(noteWithFrontmatter, "model", "claude-sonnet-4-5")
+	if !strings.Contains(got, "start_time: 13:50\nmodel: claude-sonnet-4-5\ntags:") {
+		t.Errorf("expected model inserted before tags, got:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "\nmodel: not frontmatter\n") {
+		t.Errorf("body must be untouched, got:\n%s", got)
+	}
+}
+
+// TestRemoveFrontmatterField verifies only the frontmatter line is removed.
+func TestRemoveFrontmatterField(t *testing.T) {
+	got := RemoveFrontmatterField(noteWithFrontmatter, "start_time")
+	if strings.Contains(got, "start_time:") || !strings.Contains(got, "\ntags:") {
+		t.Errorf("expected start_time removed, got:\n%s", got)
+	}
+	if got := RemoveFrontmatterField(noteWithFrontmatter, "model"); got != noteWithFrontmatter {
+		t.Errorf("missing key and body must be untouched, got:\n%s", got)
+	}
+}
+
+// TestAddUsage verifies usage accumulates across calls.
+func TestAddUsage(t *testing.T) {
+	content := AddUsage(noteWithFrontmatter, Usage{Model: "claude-sonnet-4-5", InputTokens: 100, OutputTokens: 20, CacheReadTokens: 5000, CostUSD: 0.25})
+	content = AddUsage(content, Usage{InputTokens: 50, OutputTokens: 30, CostUSD: 0.5})
+
+	u := ReadUsage(content)
+	want := Usage{Model: "claude-sonnet-4-5", InputTokens: 150, OutputTokens: 50, CacheReadTokens: 5000, CostUSD: 0.75}
+	if u != want {
+		t.Errorf("ReadUsage: got %+v, want %+v", u, want)
+	}
+	if !strings.Contains(content, "cost_usd: 0.7500\ntags:") {
+		t.Errorf("expected cost_usd before tags, got:\n%s", content)
+	}
+}
+
+// TestFormatTokens verifies compact token rendering.
+func TestFormatTokens(t *testing.T) {
+	for n, want := range map[int64]string{950: "950", 12345: "12.3k", 4_500_000: "4.5M"} {
+		if got := FormatTokens(n); got != want {
+			t.Errorf("FormatTokens(%d): got %q, want %q", n, got, want)
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package obsidian
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/valentinclaes/claude-hooks/internal/transcript"
 )
 
 // Pre-compiled regexes for stripping system-injected XML tags.
@@ -61,22 +63,6 @@ func StripSystemTags(prompt string) string {
 	return strings.TrimSpace(prompt)
 }
 
-// Truncate truncates text and appends a note with total char count.
-func Truncate(text string, maxLen int) string {
-	if len(text) <= maxLen {
-		return text
-	}
-	return text[:maxLen] + fmt.Sprintf("\n\n... (truncated, %d chars total)", len(text))
-}
-
-// TruncateSimple truncates text with a simple "... (truncated)" suffix.
-func TruncateSimple(text string, maxLen int) string {
-	if len(text) <= maxLen {
-		return text
-	}
-	return text[:maxLen] + "\n\n... (truncated)"
-}
-
 // FormatCalloutContent prefixes each line with "> ".
 func FormatCalloutContent(text string) string {
 	lines := strings.Split(text, "\n")
@@ -86,50 +72,95 @@ func FormatCalloutContent(text string) string {
 	return strings.Join(lines, "\n")
 }
 
-// BuildFrontmatter generates YAML frontmatter for a new session file.
-func BuildFrontmatter(date, sessionID, project, startTime, resumedFrom string) string {
-	projectTag := strings.ToLower(regexp.MustCompile(`\s+`).ReplaceAllString(project, "-"))
-
-	var sb strings.Builder
-	sb.WriteString("---\n")
-	sb.WriteString("date: " + date + "\n")
-	sb.WriteString("session_id: " + sessionID + "\n")
-	sb.WriteString("project: " + project + "\n")
-	sb.WriteString("start_time: " + startTime + "\n")
-	if resumedFrom != "" {
-		sb.WriteString("resumed_from: \"[[" + resumedFrom + "]]\"\n")
-	}
-	sb.WriteString("tags:\n")
-	sb.WriteString("  - claude-session\n")
-	sb.WriteString("  - " + projectTag + "\n")
-	sb.WriteString("---\n")
-	sb.WriteString("\n# Claude Session - " + project + "\n")
-
-	if resumedFrom != "" {
-		parentName := filepath.Base(resumedFrom)
-		sb.WriteString("Resumed from [[" + resumedFrom + "|" + parentName + "]]\n")
-	}
-
-	sb.WriteString("\n---\n")
-	return sb.String()
+// BuildFrontmatter renders the session template: the YAML frontmatter and
+// heading of a new session file.
+func BuildFrontmatter(s SessionInfo) string {
+	return render(TemplateSession, s)
 }
 
 // FormatPromptEntry formats a user prompt as an Obsidian callout (expanded).
-func FormatPromptEntry(promptNum int, timeStr, cwd, promptText string) string {
-	calloutContent := FormatCalloutContent(promptText)
-	return fmt.Sprintf("\n> [!user]+ #%d - You (%s)\n> **cwd**: ``%s``\n>\n%s\n\n---\n", promptNum, timeStr, cwd, calloutContent)
+func FormatPromptEntry(e EntryInfo) string {
+	return render(TemplatePrompt, e)
 }
 
 // FormatPlanEntry formats a plan as a collapsed Obsidian callout.
-func FormatPlanEntry(timeStr, planText string) string {
-	calloutContent := FormatCalloutContent(planText)
-	return fmt.Sprintf("\n> [!plan]- Claude's Plan (%s)\n%s\n\n---\n", timeStr, calloutContent)
+func FormatPlanEntry(e EntryInfo) string {
+	return render(TemplatePlan, e)
 }
 
 // FormatResponseEntry formats a response as a collapsed Obsidian callout.
-func FormatResponseEntry(timeStr, responseText string) string {
-	calloutContent := FormatCalloutContent(responseText)
-	return fmt.Sprintf("\n> [!claude]- Claude (%s)\n%s\n\n---\n", timeStr, calloutContent)
+func FormatResponseEntry(e EntryInfo) string {
+	return render(TemplateResponse, e)
+}
+
+// FormatCompactEntry formats the marker of a context compaction, with its
+// custom instructions if any.
+func FormatCompactEntry(c CompactInfo) string {
+	return render(TemplateCompact, c)
+}
+
+// FormatCompactSummaryEntry formats the summary a compaction left in place
+// of the conversation as a collapsed Obsidian callout.
+func FormatCompactSummaryEntry(e EntryInfo) string {
+	return render(TemplateSummary, e)
+}
+
+// FormatSubagentEntry formats a sub-agent run as a collapsed Obsidian
+// callout holding its prompt and result as nested callouts.
+func FormatSubagentEntry(s SubagentInfo) string {
+	return render(TemplateSubagent, s)
+}
+
+// FormatNotificationEntry formats a notification Claude Code showed the user
+// as an Obsidian callout.
+func FormatNotificationEntry(n NotificationInfo) string {
+	return render(TemplateNotify, n)
+}
+
+// NewNotePath returns a free path for a session note started at start:
+// {date}_{HHMM}.md, with a slug of title appended if slug is set, and a
+// counter on collision.
+func NewNotePath(projectDir string, start time.Time, title string, slug bool) string {
+	baseName := start.Format("2006-01-02_1504")
+	if s := Slugify(title); slug && s != "" {
+		baseName += "_" + s
+	}
+	path := filepath.Join(projectDir, baseName+".md")
+	for counter := 2; ; counter++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = filepath.Join(projectDir, fmt.Sprintf("%s_%d.md", baseName, counter))
+	}
+}
+
+// SessionNotes maps the session_id of every session note in the vault to the
+// note's path. Hidden folders (.obsidian, .git) are skipped.
+func SessionNotes(vaultDir string) map[string]string {
+	notes := make(map[string]string)
+	filepath.WalkDir(vaultDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != vaultDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		if id := FrontmatterField(string(content), "session_id"); id != "" {
+			notes[id] = path
+		}
+		return nil
+	})
+	return notes
 }
 
 // FindParentSession searches for a parent session's vault path by reading
@@ -151,30 +182,19 @@ func FindParentSession(sessionID, claudeProjectsDir, vaultDir string) string {
 		return ""
 	}
 
-	// Read first ~20 lines to find parentUuid
-	data, err := os.ReadFile(transcriptFile)
+	// Read the first ~20 entries to find parentUuid
+	f, err := transcript.Open(transcriptFile, 0)
 	if err != nil {
 		return ""
 	}
-	lines := strings.SplitN(string(data), "\n", 21)
-	if len(lines) > 20 {
-		lines = lines[:20]
-	}
-
 	parentID := ""
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		var obj struct {
-			ParentUuid string `json:"parentUuid"`
-		}
-		if err := json.Unmarshal([]byte(line), &obj); err == nil && obj.ParentUuid != "" {
-			parentID = obj.ParentUuid
+	for i := 0; i < 20 && f.Next(); i++ {
+		if e := f.Entry(); e.ParentUUID != "" {
+			parentID = e.ParentUUID
 			break
 		}
 	}
+	f.Close()
 	if parentID == "" {
 		return ""
 	}
@@ -198,8 +218,7 @@ func FindParentSession(sessionID, claudeProjectsDir, vaultDir string) string {
 			if err != nil {
 				return nil
 			}
-			result = strings.ReplaceAll(rel, "\\", "/")
-			result = strings.TrimSuffix(result, ".md")
+			result = strings.TrimSuffix(filepath.ToSlash(rel), ".md")
 			return filepath.SkipAll
 		}
 		return nil
@@ -1,13 +1,15 @@
 package obsidian
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
 
 // TestBuildFrontmatter_NoResume verifies output matches PowerShell for a new session.
 func TestBuildFrontmatter_NoResume(t *testing.T) {
-	got := BuildFrontmatter("2026-02-13", "abc-123", "Coding", "13:50", "")
+	got := BuildFrontmatter(SessionInfo{Date: "2026-02-13", SessionID: "abc-123", Project: "Coding", StartTime: "13:50"})
 	want := "---\n" +
 		"date: 2026-02-13\n" +
 		"session_id: abc-123\n" +
@@ -26,7 +28,8 @@ func TestBuildFrontmatter_NoResume(t *testing.T) {
 
 // TestBuildFrontmatter_WithResume verifies resumed session output.
 func TestBuildFrontmatter_WithResume(t *testing.T) {
-	got := BuildFrontmatter("2026-02-13", "abc-123", "Coding", "13:50", "Coding/2026-02-13_1200")
+	got := BuildFrontmatter(SessionInfo{Date: "2026-02-13", SessionID: "abc-123", Project: "Coding", StartTime: "13:50",
+		ResumedFrom: "Coding/2026-02-13_1200"})
 	want := "---\n" +
 		"date: 2026-02-13\n" +
 		"session_id: abc-123\n" +
@@ -47,7 +50,7 @@ func TestBuildFrontmatter_WithResume(t *testing.T) {
 
 // TestFormatPromptEntry verifies prompt callout matches PS format exactly.
 func TestFormatPromptEntry(t *testing.T) {
-	got := FormatPromptEntry(1, "13:50:22", `C:\Coding`, "can you help me")
+	got := FormatPromptEntry(EntryInfo{SessionInfo: SessionInfo{PromptNum: 1, Cwd: `C:\Coding`}, Time: "13:50:22", Text: "can you help me"})
 	want := "\n> [!user]+ #1 - You (13:50:22)\n" +
 		"> **cwd**: ``C:\\Coding``\n" +
 		">\n" +
@@ -60,7 +63,8 @@ func TestFormatPromptEntry(t *testing.T) {
 
 // TestFormatPromptEntry_MultiLine verifies multi-line prompt gets > prefix on each line.
 func TestFormatPromptEntry_MultiLine(t *testing.T) {
-	got := FormatPromptEntry(2, "14:00:00", `C:\Work`, "line one\nline two\nline three")
+	got := FormatPromptEntry(EntryInfo{SessionInfo: SessionInfo{PromptNum: 2, Cwd: `C:\Work`}, Time: "14:00:00",
+		Text: "line one\nline two\nline three"})
 	want := "\n> [!user]+ #2 - You (14:00:00)\n" +
 		"> **cwd**: ``C:\\Work``\n" +
 		">\n" +
@@ -73,7 +77,7 @@ func TestFormatPromptEntry_MultiLine(t *testing.T) {
 
 // TestFormatResponseEntry verifies response callout matches PS format.
 func TestFormatResponseEntry(t *testing.T) {
-	got := FormatResponseEntry("13:52:50", "Done. All changes applied.")
+	got := FormatResponseEntry(EntryInfo{Time: "13:52:50", Text: "Done. All changes applied."})
 	want := "\n> [!claude]- Claude (13:52:50)\n" +
 		"> Done. All changes applied.\n" +
 		"\n---\n"
@@ -84,7 +88,7 @@ func TestFormatResponseEntry(t *testing.T) {
 
 // TestFormatPlanEntry verifies plan callout matches PS format.
 func TestFormatPlanEntry(t *testing.T) {
-	got := FormatPlanEntry("13:52:50", "Step 1: Do X\nStep 2: Do Y")
+	got := FormatPlanEntry(EntryInfo{Time: "13:52:50", Text: "Step 1: Do X\nStep 2: Do Y"})
 	want := "\n> [!plan]- Claude's Plan (13:52:50)\n" +
 		"> Step 1: Do X\n> Step 2: Do Y\n" +
 		"\n---\n"
@@ -93,6 +97,72 @@ func TestFormatPlanEntry(t *testing.T) {
 	}
 }
 
+// TestFormatCompactEntry verifies the compaction marker, with and without
+// custom instructions, and the collapsed summary callout.
+func TestFormatCompactEntry(t *testing.T) {
+	got := FormatCompactEntry(CompactInfo{EntryInfo: EntryInfo{Time: "14:02:11"}, Trigger: "auto"})
+	want := "\n> [!compact] Context compacted (auto, 14:02:11)\n\n---\n"
+	if got != want {
+		t.Errorf("FormatCompactEntry (auto) mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = FormatCompactEntry(CompactInfo{EntryInfo: EntryInfo{Time: "14:02:11", Text: "keep the API notes"}, Trigger: "manual"})
+	want = "\n> [!compact] Context compacted (manual, 14:02:11)\n" +
+		"> **Instructions**:\n> keep the API notes\n" +
+		"\n---\n"
+	if got != want {
+		t.Errorf("FormatCompactEntry (manual) mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = FormatCompactSummaryEntry(EntryInfo{Time: "14:03:00", Text: "We fixed X.\nNext: Y."})
+	want = "\n> [!compact]- Compaction summary (14:03:00)\n> We fixed X.\n> Next: Y.\n\n---\n"
+	if got != want {
+		t.Errorf("FormatCompactSummaryEntry mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestFormatSubagentEntry verifies the prompt and result are nested in the
+// collapsed sub-agent callout.
+func TestFormatSubagentEntry(t *testing.T) {
+	got := FormatSubagentEntry(SubagentInfo{
+		EntryInfo:   EntryInfo{Time: "14:05:00", Text: "Auth is in auth.go.\nSee Login()."},
+		Prompt:      "Find where auth happens",
+		Description: "Find auth code",
+		AgentType:   "Explore",
+	})
+	want := "\n> [!agent]- Explore: Find auth code (14:05:00)\n" +
+		"> > [!user]- Prompt\n> > Find where auth happens\n>\n" +
+		"> > [!claude]- Result\n> > Auth is in auth.go.\n> > See Login().\n" +
+		"\n---\n"
+	if got != want {
+		t.Errorf("FormatSubagentEntry mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := FormatSubagentEntry(SubagentInfo{EntryInfo: EntryInfo{Time: "14:05:00"}}); !strings.HasPrefix(got, "\n> [!agent]- Sub-agent (14:05:00)\n") {
+		t.Errorf("expected generic title without a Task call, got:\n%s", got)
+	}
+}
+
+// TestFormatNotificationEntry verifies the notification callout, with and
+// without a notification type.
+func TestFormatNotificationEntry(t *testing.T) {
+	got := FormatNotificationEntry(NotificationInfo{
+		EntryInfo: EntryInfo{Time: "14:10:00", Text: "Claude needs your permission to use Bash"},
+		Kind:      "permission_prompt",
+	})
+	want := "\n> [!notify] Notification - permission_prompt (14:10:00)\n" +
+		"> Claude needs your permission to use Bash\n" +
+		"\n---\n"
+	if got != want {
+		t.Errorf("FormatNotificationEntry mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = FormatNotificationEntry(NotificationInfo{EntryInfo: EntryInfo{Time: "14:10:00", Text: "Claude is waiting for your input"}})
+	if !strings.HasPrefix(got, "\n> [!notify] Notification (14:10:00)\n") {
+		t.Errorf("expected plain title without a type, got:\n%s", got)
+	}
+}
+
 // TestTruncate verifies truncation with char count matches PS behavior.
 func TestTruncate(t *testing.T) {
 	short := "hello"
@@ -121,7 +191,7 @@ func TestTruncateSimple(t *testing.T) {
 // TestProjectTag_WhitespaceCollapse verifies projectTag handles tabs/multiple spaces.
 func TestProjectTag_WhitespaceCollapse(t *testing.T) {
 	// PS: $project.ToLower() -replace '\s+', '-'
-	got := BuildFrontmatter("2026-02-13", "id", "My  Project\tName", "10:00", "")
+	got := BuildFrontmatter(SessionInfo{Date: "2026-02-13", SessionID: "id", Project: "My  Project\tName", StartTime: "10:00"})
 	if !strings.Contains(got, "  - my-project-name\n") {
 		t.Errorf("projectTag should collapse whitespace to single hyphen, got:\n%s", got)
 	}
@@ -136,3 +206,26 @@ func TestStripSystemTags(t *testing.T) {
 		t.Errorf("StripSystemTags: got %q, want %q", got, want)
 	}
 }
+
+// TestFindParentSession verifies the parent note is found via the transcript's parentUuid.
+func TestFindParentSession(t *testing.T) {
+	projects := t.TempDir()
+	vault := t.TempDir()
+
+	transcriptDir := filepath.Join(projects, "-home-me-coding")
+	os.MkdirAll(transcriptDir, 0755)
+	os.WriteFile(filepath.Join(transcriptDir, "child-id.jsonl"), []byte(
+		`{"type":"summary","summary":"x"}`+"\n"+
+			`{"type":"user","uuid":"u1","parentUuid":"parent-id","message":{"role":"user","content":"hi"}}`+"\n"), 0644)
+
+	noteDir := filepath.Join(vault, "Coding")
+	os.MkdirAll(noteDir, 0755)
+	os.WriteFile(filepath.Join(noteDir, "2026-02-13_1200.md"), []byte("---\nsession_id: parent-id\n---\n"), 0644)
+
+	if got, want := FindParentSession("child-id", projects, vault), "Coding/2026-02-13_1200"; got != want {
+		t.Errorf("FindParentSession: got %q, want %q", got, want)
+	}
+	if got := FindParentSession("unknown-id", projects, vault); got != "" {
+		t.Errorf("FindParentSession for unknown session: got %q, want empty", got)
+	}
+}
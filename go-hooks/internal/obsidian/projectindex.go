@@ -0,0 +1,112 @@
+package obsidian
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/valentinclaes/claude-hooks/internal/atomicfile"
+)
+
+// excerptLen is the number of characters of the first prompt shown in the
+// project index.
+const excerptLen = 100
+
+// maxChain caps how many resumed-from hops are followed for one session.
+const maxChain = 10
+
+// RebuildProjectIndex rebuilds {project}/{indexName}.md, listing every session
+// of the project newest first with its title (or first prompt) and the chain
+// of sessions it resumed. An empty indexName disables the index.
+func RebuildProjectIndex(vaultDir, project, indexName string) error {
+	if indexName == "" || project == "" {
+		return nil
+	}
+	sessions := collectProjectSessions(vaultDir, project, "*_*.md")
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].RelPath > sessions[j].RelPath // file names sort by start time
+	})
+	byPath := make(map[string]sessionEntry, len(sessions))
+	var total rollupTotals
+	for _, s := range sessions {
+		byPath[s.RelPath] = s
+		total.add(s)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("---\nproject: %s\nsessions: %d\nprompts: %d\n", project, total.Sessions, total.Prompts))
+	if !total.Usage.IsZero() {
+		sb.WriteString(fmt.Sprintf("cost_usd: %.4f\n", total.Usage.CostUSD))
+	}
+	sb.WriteString("tags:\n  - claude-project\n---\n\n# " + project + " - Claude Sessions\n")
+	sb.WriteString(fmt.Sprintf("\n*%d sessions, %d prompts, %s*\n", total.Sessions, total.Prompts, formatMinutes(total.Minutes)))
+	if !total.Usage.IsZero() {
+		sb.WriteString(formatUsageTotal(total.Usage) + "\n")
+	}
+
+	date := ""
+	for _, s := range sessions {
+		if s.Date != date {
+			date = s.Date
+			sb.WriteString("\n## [[" + date + "]]\n")
+		}
+		line := "- [[" + s.RelPath + "|" + s.alias() + "]]" + s.meta()
+		if s.Title == "" && s.Excerpt != "" {
+			line += " - " + s.Excerpt
+		}
+		sb.WriteString(line + "\n")
+		if chain := resumeChain(s, byPath); chain != "" {
+			sb.WriteString("  - resumed from " + chain + "\n")
+		}
+	}
+
+	return atomicfile.WriteFile(filepath.Join(vaultDir, project, indexName+".md"), []byte(sb.String()), 0644)
+}
+
+// resumeChain renders the sessions s resumed, nearest first, following
+// resumed_from links through the sessions in byPath.
+func resumeChain(s sessionEntry, byPath map[string]sessionEntry) string {
+	var links []string
+	seen := map[string]bool{s.RelPath: true}
+	for parent := s.ResumedFrom; parent != "" && !seen[parent] && len(links) < maxChain; {
+		seen[parent] = true
+		p, ok := byPath[parent]
+		if !ok {
+			links = append(links, "[["+parent+"|"+filepath.Base(parent)+"]]")
+			break
+		}
+		links = append(links, "[["+p.RelPath+"|"+p.Date+" "+p.Time+"]]")
+		parent = p.ResumedFrom
+	}
+	return strings.Join(links, " ← ")
+}
+
+// firstPromptExcerpt returns the first line of the first prompt callout,
+// shortened to excerptLen characters.
+func firstPromptExcerpt(content string) string {
+	loc := userCallout.FindStringIndex(content)
+	if loc == nil {
+		return ""
+	}
+	lines := strings.Split(content[loc[1]:], "\n")
+	for _, line := range lines[1:] { // skip the callout title
+		if !strings.HasPrefix(line, ">") {
+			break
+		}
+		text := strings.TrimSpace(strings.TrimPrefix(line, ">"))
+		if text == "" || strings.HasPrefix(text, "**cwd**") {
+			continue
+		}
+		if utf8.RuneCountInString(text) > excerptLen {
+			text = text[:cutIndex(text, excerptLen)] + "..."
+		}
+		return text
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package obsidian
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRebuildProjectIndex verifies sessions are listed newest first, grouped by
+// day, with first-prompt excerpts and resumed-from chains.
+func TestRebuildProjectIndex(t *testing.T) {
+	setHome(t)
+	vault := t.TempDir()
+	dir := filepath.Join(vault, "Coding")
+	os.MkdirAll(dir, 0755)
+	notes := map[string]string{
+		"2026-02-12_0900.md": "---\nsession_id: a\nduration: 10min\ntags:\n  - claude-session\n---\n" +
+			"\n> [!user]+ #1 - You (09:00:00)\n> **cwd**: ``C:\\Coding``\n>\n> set up the project\n> with tests\n\n---\n",
+		"2026-02-12_1400.md": "---\nsession_id: b\nresumed_from: \"[[Coding/2026-02-12_0900]]\"\ntags:\n  - claude-session\n---\n" +
+			"\n> [!user]+ #1 - You (14:00:00)\n>\n> " + strings.Repeat("é", 120) + "\n\n---\n",
+		"2026-02-13_1000.md": "---\nsession_id: c\nresumed_from: \"[[Coding/2026-02-12_1400]]\"\ntags:\n  - claude-session\n---\n" +
+			"\n> [!user]+ #1 - You (10:00:00)\n> continue\n\n---\n",
+	}
+	for name, content := range notes {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+
+	if err := RebuildProjectIndex(vault, "Coding", "_index"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "_index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+
+	want := "---\nproject: Coding\nsessions: 3\nprompts: 3\ntags:\n  - claude-project\n---\n\n# Coding - Claude Sessions\n" +
+		"\n*3 sessions, 3 prompts, 10min*\n" +
+		"\n## [[2026-02-13]]\n" +
+		"- [[Coding/2026-02-13_1000|10:00]] (1 prompts) - continue\n" +
+		"  - resumed from [[Coding/2026-02-12_1400|2026-02-12 14:00]] ← [[Coding/2026-02-12_0900|2026-02-12 09:00]]\n" +
+		"\n## [[2026-02-12]]\n" +
+		"- [[Coding/2026-02-12_1400|14:00]] (1 prompts) - " + strings.Repeat("é", 100) + "...\n" +
+		"  - resumed from [[Coding/2026-02-12_0900|2026-02-12 09:00]]\n" +
+		"- [[Coding/2026-02-12_0900|09:00]] (10min, 1 prompts) - set up the project\n"
+	if got != want {
+		t.Errorf("project index mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Rebuilding must not pick up the index itself
+	RebuildProjectIndex(vault, "Coding", "_index")
+	if data, _ := os.ReadFile(filepath.Join(dir, "_index.md")); string(data) != got {
+		t.Errorf("rebuild changed the index:\n%s", data)
+	}
+}
+
+// TestRebuildProjectIndex_Disabled verifies an empty name writes nothing.
+func TestRebuildProjectIndex_Disabled(t *testing.T) {
+	vault := t.TempDir()
+	writeSessionNote(t, vault, "Coding", "2026-02-12_0900.md", "10min", "0.1000")
+	if err := RebuildProjectIndex(vault, "Coding", ""); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(vault, "Coding"))
+	if len(entries) != 1 {
+		t.Errorf("expected only the session note, got %d entries", len(entries))
+	}
+}
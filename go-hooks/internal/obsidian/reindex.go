@@ -0,0 +1,227 @@
+package obsidian
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Actions reported by Reindex for a generated note.
+const (
+	Created = "created"
+	Updated = "updated"
+	Removed = "removed"
+)
+
+var (
+	dailyNameRe   = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\.md$`)
+	weeklyNameRe  = regexp.MustCompile(`^\d{4}-W\d{2}\.md$`)
+	monthlyNameRe = regexp.MustCompile(`^\d{4}-\d{2}\.md$`)
+)
+
+// Change is one generated note Reindex created, updated or removed.
+type Change struct {
+	Path   string // relative to the vault, with forward slashes
+	Action string
+}
+
+// ReindexReport lists the notes Reindex changed and counts the ones it left as they were.
+type ReindexReport struct {
+	Changes   []Change
+	Unchanged int
+}
+
+// period is a weekly or monthly rollup: any day inside it, and its bounds.
+type period struct {
+	day      time.Time
+	from, to string
+}
+
+// Reindex regenerates the daily, weekly, monthly and project index notes
+// for the days between from and to (YYYY-MM-DD, inclusive; "" leaves that
+// end open) from the session notes in the vault. Generated notes of days or
+// periods that no longer have sessions are removed. Running it again without
+// vault changes changes nothing.
+func Reindex(vaultDir, from, to, projectIndex string) (ReindexReport, error) {
+	var r ReindexReport
+	all, err := collectSessions(vaultDir, "*_*.md")
+	if err != nil {
+		return r, err
+	}
+	inRange := func(start, end string) bool {
+		return (from == "" || end >= from) && (to == "" || start <= to)
+	}
+
+	days := make(map[string]bool)
+	weeks := make(map[string]period)
+	months := make(map[string]period)
+	projects := make(map[string]bool)
+	for _, s := range all {
+		day, err := time.Parse("2006-01-02", s.Date)
+		if err != nil {
+			continue
+		}
+		days[s.Date] = true
+		// A session that ran past midnight is also listed under the day it ended
+		if end := s.End.Format("2006-01-02"); !s.End.IsZero() && end > s.Date &&
+			end <= day.AddDate(0, 0, continuedLookback).Format("2006-01-02") {
+			days[end] = true
+		}
+		name, p := weekOf(day)
+		weeks[name] = p
+		name, p = monthOf(day)
+		months[name] = p
+		if inRange(s.Date, s.Date) {
+			projects[s.Project] = true
+		}
+	}
+
+	for _, date := range sortedKeys(days) {
+		if !inRange(date, date) {
+			continue
+		}
+		err := r.rebuild(vaultDir, date+".md", func() error { return RebuildDailyIndex(vaultDir, date) })
+		if err != nil {
+			return r, err
+		}
+	}
+	for _, name := range sortedKeys(weeks) {
+		if p := weeks[name]; inRange(p.from, p.to) {
+			if err := r.rebuild(vaultDir, name+".md", func() error { return RebuildWeeklyIndex(vaultDir, p.day) }); err != nil {
+				return r, err
+			}
+		}
+	}
+	for _, name := range sortedKeys(months) {
+		if p := months[name]; inRange(p.from, p.to) {
+			if err := r.rebuild(vaultDir, name+".md", func() error { return RebuildMonthlyIndex(vaultDir, p.day) }); err != nil {
+				return r, err
+			}
+		}
+	}
+	if projectIndex != "" {
+		for _, project := range sortedKeys(projects) {
+			rel := project + "/" + projectIndex + ".md"
+			if err := r.rebuild(vaultDir, rel, func() error { return RebuildProjectIndex(vaultDir, project, projectIndex) }); err != nil {
+				return r, err
+			}
+		}
+	}
+
+	if err := r.removeStale(vaultDir, inRange, days, weeks, months); err != nil {
+		return r, err
+	}
+	sort.Slice(r.Changes, func(i, j int) bool { return r.Changes[i].Path < r.Changes[j].Path })
+	return r, nil
+}
+
+// rebuild runs build and records how it changed the note at rel.
+func (r *ReindexReport) rebuild(vaultDir, rel string, build func() error) error {
+	path := filepath.Join(vaultDir, filepath.FromSlash(rel))
+	before, readErr := os.ReadFile(path)
+	if err := build(); err != nil {
+		return fmt.Errorf("rebuild %s: %w", rel, err)
+	}
+	after, err := os.ReadFile(path)
+	switch {
+	case err != nil:
+		// nothing written
+	case readErr != nil:
+		r.Changes = append(r.Changes, Change{Path: rel, Action: Created})
+	case !bytes.Equal(before, after):
+		r.Changes = append(r.Changes, Change{Path: rel, Action: Updated})
+	default:
+		r.Unchanged++
+	}
+	return nil
+}
+
+// removeStale deletes the generated daily, weekly and monthly notes in range
+// whose day or period has no sessions left. Only notes carrying the tag the
+// hooks write are touched, so a user's own note named like a date is kept.
+func (r *ReindexReport) removeStale(vaultDir string, inRange func(start, end string) bool,
+	days map[string]bool, weeks, months map[string]period) error {
+	entries, err := os.ReadDir(vaultDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".md") {
+			continue
+		}
+		base := strings.TrimSuffix(name, ".md")
+		var tag, start, end string
+		var live bool
+		switch {
+		case dailyNameRe.MatchString(name):
+			tag, start, end, live = "claude-daily", base, base, days[base]
+		case weeklyNameRe.MatchString(name):
+			_, live = weeks[base]
+			tag = "claude-weekly"
+		case monthlyNameRe.MatchString(name):
+			_, live = months[base]
+			tag, start, end = "claude-monthly", base+"-01", base+"-31"
+		default:
+			continue
+		}
+		if live {
+			continue
+		}
+
+		path := filepath.Join(vaultDir, name)
+		data, err := os.ReadFile(path)
+		if err != nil || !hasTag(string(data), tag) {
+			continue
+		}
+		if tag == "claude-weekly" {
+			start, end = FrontmatterField(string(data), "start"), FrontmatterField(string(data), "end")
+		}
+		if !inRange(start, end) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		r.Changes = append(r.Changes, Change{Path: name, Action: Removed})
+	}
+	return nil
+}
+
+// weekOf returns the ISO week name (YYYY-Www) and bounds of the week containing day.
+func weekOf(day time.Time) (string, period) {
+	year, week := day.ISOWeek()
+	start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)) // Monday
+	end := start.AddDate(0, 0, 6)
+	return fmt.Sprintf("%04d-W%02d", year, week), period{day, start.Format("2006-01-02"), end.Format("2006-01-02")}
+}
+
+// monthOf returns the name (YYYY-MM) and bounds of the month containing day.
+func monthOf(day time.Time) (string, period) {
+	start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+	return day.Format("2006-01"), period{day, start.Format("2006-01-02"), end.Format("2006-01-02")}
+}
+
+// hasTag reports whether the note's frontmatter lists tag.
+func hasTag(content, tag string) bool {
+	end := frontmatterEnd(content)
+	if end < 0 {
+		return false
+	}
+	return strings.Contains(content[:end], "\n  - "+tag+"\n")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,72 @@
+package obsidian
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReindex verifies every index is rebuilt, stale generated notes are
+// removed, user notes are kept and a second run changes nothing.
+func TestReindex(t *testing.T) {
+	setHome(t)
+	vault := t.TempDir()
+	writeSessionNote(t, vault, "Coding", "2026-02-10_0900.md", "30min", "0.2500")
+	writeSessionNote(t, vault, "api", "2026-02-12_1400.md", "15min", "0.5000")
+	os.WriteFile(filepath.Join(vault, "2026-02-11.md"), []byte("---\ndate: 2026-02-11\ntags:\n  - claude-daily\n---\n"), 0644)
+	os.WriteFile(filepath.Join(vault, "2026-02-13.md"), []byte("# My own note\n"), 0644)
+	if err := RebuildDailyIndex(vault, "2026-02-10"); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Reindex(vault, "", "", "_index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{"2026-02-11.md", Removed},
+		{"2026-02-12.md", Created},
+		{"2026-02.md", Created},
+		{"2026-W07.md", Created},
+		{"Coding/_index.md", Created},
+		{"api/_index.md", Created},
+	}
+	if len(report.Changes) != len(want) || report.Unchanged != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	for i, c := range want {
+		if report.Changes[i] != c {
+			t.Errorf("change %d = %+v, want %+v", i, report.Changes[i], c)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(vault, "2026-02-13.md")); err != nil {
+		t.Error("untagged user note must be kept")
+	}
+
+	report, err = Reindex(vault, "", "", "_index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 0 || report.Unchanged != 6 {
+		t.Errorf("second run should change nothing, got %+v", report)
+	}
+}
+
+// TestReindex_Range verifies only indexes covering the requested days are touched.
+func TestReindex_Range(t *testing.T) {
+	setHome(t)
+	vault := t.TempDir()
+	writeSessionNote(t, vault, "Coding", "2026-01-05_0900.md", "30min", "0.2500")
+	writeSessionNote(t, vault, "Coding", "2026-02-10_0900.md", "15min", "0.5000")
+
+	report, err := Reindex(vault, "2026-02-10", "2026-02-10", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 3 {
+		t.Errorf("expected the day, week and month of 2026-02-10, got %+v", report.Changes)
+	}
+	if _, err := os.Stat(filepath.Join(vault, "2026-01-05.md")); !os.IsNotExist(err) {
+		t.Error("days outside the range must not be rebuilt")
+	}
+}
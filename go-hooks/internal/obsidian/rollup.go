@@ -0,0 +1,167 @@
+package obsidian
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valentinclaes/claude-hooks/internal/atomicfile"
+)
+
+var durationPartRe = regexp.MustCompile(`(\d+)\s*(h|min)`)
+
+// rollupTotals aggregates sessions for one row of a rollup note.
+type rollupTotals struct {
+	Sessions int
+	Prompts  int
+	Minutes  int
+	Usage    Usage
+}
+
+func (t *rollupTotals) add(s sessionEntry) {
+	t.Sessions++
+	t.Prompts += s.Prompts
+	t.Minutes += durationMinutes(s.Duration)
+	t.Usage = sumUsage(t.Usage, s.Usage)
+}
+
+// RebuildRollups rebuilds the weekly (YYYY-Www.md, ISO week) and monthly
+// (YYYY-MM.md) notes covering date.
+func RebuildRollups(vaultDir, date string) error {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return err
+	}
+	if err := RebuildWeeklyIndex(vaultDir, day); err != nil {
+		return err
+	}
+	return RebuildMonthlyIndex(vaultDir, day)
+}
+
+// RebuildWeeklyIndex rebuilds the note of the ISO week containing day.
+func RebuildWeeklyIndex(vaultDir string, day time.Time) error {
+	name, p := weekOf(day)
+	from, to := p.from, p.to
+
+	// A week spans at most two months
+	months := []string{from[:7]}
+	if m := to[:7]; m != months[0] {
+		months = append(months, m)
+	}
+	var sessions []sessionEntry
+	for _, m := range months {
+		found, err := collectSessions(vaultDir, m+"-*.md")
+		if err != nil {
+			return err
+		}
+		for _, s := range found {
+			if s.Date >= from && s.Date <= to {
+				sessions = append(sessions, s)
+			}
+		}
+	}
+
+	fm := "week: " + name + "\nstart: " + from + "\nend: " + to + "\n"
+	return writeRollup(filepath.Join(vaultDir, name+".md"), name, fm, "claude-weekly", sessions)
+}
+
+// RebuildMonthlyIndex rebuilds the note of the month containing day.
+func RebuildMonthlyIndex(vaultDir string, day time.Time) error {
+	name := day.Format("2006-01")
+	sessions, err := collectSessions(vaultDir, name+"-*.md")
+	if err != nil {
+		return err
+	}
+	return writeRollup(filepath.Join(vaultDir, name+".md"), name, "month: "+name+"\n", "claude-monthly", sessions)
+}
+
+// writeRollup writes a rollup note with totals per day (linking the daily
+// notes) and per project. Nothing is written for a period without sessions.
+func writeRollup(path, title, frontmatter, tag string, sessions []sessionEntry) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	var total rollupTotals
+	days := make(map[string]*rollupTotals)
+	projects := make(map[string]*rollupTotals)
+	for _, s := range sessions {
+		total.add(s)
+		if days[s.Date] == nil {
+			days[s.Date] = &rollupTotals{}
+		}
+		days[s.Date].add(s)
+		if projects[s.Project] == nil {
+			projects[s.Project] = &rollupTotals{}
+		}
+		projects[s.Project].add(s)
+	}
+
+	dayOrder := make([]string, 0, len(days))
+	for d := range days {
+		dayOrder = append(dayOrder, d)
+	}
+	sort.Strings(dayOrder)
+	projectOrder := make([]string, 0, len(projects))
+	for p := range projects {
+		projectOrder = append(projectOrder, p)
+	}
+	sort.Slice(projectOrder, func(i, j int) bool {
+		return strings.ToLower(projectOrder[i]) < strings.ToLower(projectOrder[j])
+	})
+
+	var sb strings.Builder
+	sb.WriteString("---\n" + frontmatter)
+	sb.WriteString(fmt.Sprintf("sessions: %d\nprompts: %d\nduration_min: %d\n", total.Sessions, total.Prompts, total.Minutes))
+	if !total.Usage.IsZero() {
+		sb.WriteString(fmt.Sprintf("input_tokens: %d\noutput_tokens: %d\ncost_usd: %.4f\n",
+			total.Usage.InputTokens, total.Usage.OutputTokens, total.Usage.CostUSD))
+	}
+	sb.WriteString("tags:\n  - " + tag + "\n---\n\n# Claude Sessions - " + title + "\n")
+	sb.WriteString(fmt.Sprintf("\n*%d sessions, %d prompts, %s*\n", total.Sessions, total.Prompts, formatMinutes(total.Minutes)))
+	if !total.Usage.IsZero() {
+		sb.WriteString(formatUsageTotal(total.Usage) + "\n")
+	}
+
+	sb.WriteString("\n## Days\n\n| Day | Sessions | Prompts | Duration | Tokens | Cost |\n|---|---|---|---|---|---|\n")
+	for _, d := range dayOrder {
+		sb.WriteString(rollupRow("[["+d+"]]", days[d]))
+	}
+	sb.WriteString("\n## Projects\n\n| Project | Sessions | Prompts | Duration | Tokens | Cost |\n|---|---|---|---|---|---|\n")
+	for _, p := range projectOrder {
+		sb.WriteString(rollupRow(p, projects[p]))
+	}
+
+	return atomicfile.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+func rollupRow(label string, t *rollupTotals) string {
+	tokens := FormatTokens(t.Usage.InputTokens) + " / " + FormatTokens(t.Usage.OutputTokens)
+	return fmt.Sprintf("| %s | %d | %d | %s | %s | $%.2f |\n",
+		label, t.Sessions, t.Prompts, formatMinutes(t.Minutes), tokens, t.Usage.CostUSD)
+}
+
+// durationMinutes parses a duration frontmatter value ("42min", "1h 5min").
+func durationMinutes(s string) int {
+	total := 0
+	for _, m := range durationPartRe.FindAllStringSubmatch(s, -1) {
+		n, _ := strconv.Atoi(m[1])
+		if m[2] == "h" {
+			n *= 60
+		}
+		total += n
+	}
+	return total
+}
+
+// formatMinutes renders minutes as "42min" or "3h 5min".
+func formatMinutes(m int) string {
+	if m < 60 {
+		return fmt.Sprintf("%dmin", m)
+	}
+	return fmt.Sprintf("%dh %dmin", m/60, m%60)
+}
@@ -0,0 +1,91 @@
+package obsidian
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSessionNote writes a minimal session note with the given duration and cost.
+func writeSessionNote(t *testing.T, vault, project, name, duration, cost string) {
+	t.Helper()
+	dir := filepath.Join(vault, project)
+	os.MkdirAll(dir, 0755)
+	content := "---\ndate: " + name[:10] + "\nsession_id: " + project + "-" + name + "\nproject: " + project +
+		"\nstart_time: 09:00\nduration: " + duration +
+		"\ninput_tokens: 1000\noutput_tokens: 500\ncost_usd: " + cost + "\ntags:\n  - claude-session\n---\n" +
+		"\n> [!user]+ #1 - You (09:00:00)\n> hi\n\n---\n"
+	os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+}
+
+// TestRebuildRollups verifies ISO week and month notes aggregate per day and
+// project, with a week spanning two months.
+func TestRebuildRollups(t *testing.T) {
+	setHome(t)
+	vault := t.TempDir()
+	// ISO week 2026-W05 runs Mon 2026-01-26 to Sun 2026-02-01
+	writeSessionNote(t, vault, "Coding", "2026-01-30_0900.md", "30min", "0.2500")
+	writeSessionNote(t, vault, "Coding", "2026-02-01_0900.md", "1h 15min", "0.5000")
+	writeSessionNote(t, vault, "api", "2026-02-01_1400.md", "15min", "0.2500")
+	writeSessionNote(t, vault, "api", "2026-02-02_0900.md", "10min", "1.0000") // next week
+
+	if err := RebuildRollups(vault, "2026-02-01"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(vault, "2026-W05.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	week := string(data)
+	for _, want := range []string{
+		"week: 2026-W05\nstart: 2026-01-26\nend: 2026-02-01\nsessions: 3\nprompts: 3\nduration_min: 120\n",
+		"cost_usd: 1.0000\ntags:\n  - claude-weekly\n---\n\n# Claude Sessions - 2026-W05\n",
+		"*3 sessions, 3 prompts, 2h 0min*\n",
+		"| [[2026-01-30]] | 1 | 1 | 30min | 1.0k / 500 | $0.25 |\n| [[2026-02-01]] | 2 | 2 | 1h 30min | 2.0k / 1.0k | $0.75 |\n",
+		"| api | 1 | 1 | 15min | 1.0k / 500 | $0.25 |\n| Coding | 2 | 2 | 1h 45min | 2.0k / 1.0k | $0.75 |\n",
+	} {
+		if !strings.Contains(week, want) {
+			t.Errorf("weekly note missing %q\ngot:\n%s", want, week)
+		}
+	}
+
+	data, err = os.ReadFile(filepath.Join(vault, "2026-02.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	month := string(data)
+	for _, want := range []string{
+		"month: 2026-02\nsessions: 3\n",
+		"  - claude-monthly\n",
+		"| [[2026-02-02]] | 1 | 1 | 10min |",
+	} {
+		if !strings.Contains(month, want) {
+			t.Errorf("monthly note missing %q\ngot:\n%s", want, month)
+		}
+	}
+	if strings.Contains(month, "2026-01-30") {
+		t.Errorf("monthly note should not include January:\n%s", month)
+	}
+}
+
+// TestRebuildWeeklyIndex_Empty verifies no note is written for a week without sessions.
+func TestRebuildWeeklyIndex_Empty(t *testing.T) {
+	vault := t.TempDir()
+	if err := RebuildWeeklyIndex(vault, time.Date(2026, 2, 12, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(vault, "2026-W07.md")); !os.IsNotExist(err) {
+		t.Error("expected no weekly note for an empty week")
+	}
+}
+
+func TestDurationMinutes(t *testing.T) {
+	for in, want := range map[string]int{"42min": 42, "1h 5min": 65, "2h": 120, "": 0} {
+		if got := durationMinutes(in); got != want {
+			t.Errorf("durationMinutes(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
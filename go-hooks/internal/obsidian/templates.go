@@ -0,0 +1,148 @@
+package obsidian
+
+import (
+	"embed"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// Template names. Each can be overridden by a {name}.tmpl file in the
+// directory given to LoadTemplates.
+const (
+	TemplateSession  = "session"
+	TemplatePrompt   = "prompt"
+	TemplatePlan     = "plan"
+	TemplateResponse = "response"
+	TemplateCompact  = "compact"
+	TemplateSummary  = "compact-summary"
+	TemplateSubagent = "subagent"
+	TemplateNotify   = "notification"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplateFS embed.FS
+
+var templateFuncs = template.FuncMap{
+	"callout": FormatCalloutContent,
+	"base":    filepath.Base,
+	"lower":   strings.ToLower,
+	"tokens":  FormatTokens,
+	"yaml":    yamlQuote,
+}
+
+var whitespaceRe = regexp.MustCompile(`\s+`)
+
+// SessionInfo is the session data available to every template.
+type SessionInfo struct {
+	Date        string // YYYY-MM-DD the session started
+	SessionID   string
+	Project     string
+	Title       string // short title from the first prompt or Claude's summary
+	Cwd         string
+	GitBranch   string
+	StartTime   string // HH:MM
+	StartedAt   string // RFC 3339 start timestamp, or ""
+	Source      string // how the session started (startup, resume, clear, compact), or ""
+	Status      string // StatusRunning while a session-start note is open, or ""
+	ResumedFrom string // vault path of the parent session note, or ""
+	PromptNum   int    // number of the current (or answered) prompt
+	Model       string
+	Tokens      Usage // usage so far, including the entry being written
+}
+
+// Statuses of a session note created by the session-start hook.
+const (
+	StatusRunning = "running"
+	StatusEnded   = "ended"
+)
+
+// ProjectTag returns the project name as a tag: lowercased, whitespace
+// collapsed to hyphens.
+func (s SessionInfo) ProjectTag() string {
+	return strings.ToLower(whitespaceRe.ReplaceAllString(s.Project, "-"))
+}
+
+// EntryInfo is the data available to entry templates.
+type EntryInfo struct {
+	SessionInfo
+	Time string // HH:MM:SS of the entry
+	Text string // entry text, already truncated
+}
+
+// SubagentInfo is the data available to the subagent template.
+type SubagentInfo struct {
+	EntryInfo          // Text holds the sub-agent's result
+	Prompt      string // the prompt the sub-agent was given
+	Description string // short task description from the Task call, or ""
+	AgentType   string // subagent_type from the Task call, or ""
+}
+
+// NotificationInfo is the data available to the notification template.
+type NotificationInfo struct {
+	EntryInfo        // Text holds the notification message
+	Kind      string // notification type (e.g. permission_prompt), or ""
+}
+
+// CompactInfo is the data available to the compact template.
+type CompactInfo struct {
+	EntryInfo        // Text holds the custom instructions, if any
+	Trigger   string // "manual" (/compact) or "auto"
+}
+
+var (
+	defaultTemplates = mustParseDefaults()
+	activeTemplates  = defaultTemplates
+)
+
+func mustParseDefaults() map[string]*template.Template {
+	tmpls := make(map[string]*template.Template)
+	for _, name := range []string{TemplateSession, TemplatePrompt, TemplatePlan, TemplateResponse,
+		TemplateCompact, TemplateSummary, TemplateSubagent, TemplateNotify} {
+		data, err := defaultTemplateFS.ReadFile("templates/" + name + ".tmpl")
+		if err != nil {
+			panic(err)
+		}
+		tmpls[name] = template.Must(parseTemplate(name, string(data)))
+	}
+	return tmpls
+}
+
+// parseTemplate parses a template, normalizing CRLF line endings so that
+// templates edited on Windows still produce valid frontmatter.
+func parseTemplate(name, text string) (*template.Template, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// LoadTemplates replaces the built-in templates with the {name}.tmpl files
+// found in dir (~/.claude/hooks/templates). Missing or invalid files keep
+// the built-in template.
+func LoadTemplates(dir string) {
+	tmpls := make(map[string]*template.Template, len(defaultTemplates))
+	for name, def := range defaultTemplates {
+		tmpls[name] = def
+		data, err := os.ReadFile(filepath.Join(dir, name+".tmpl"))
+		if err != nil {
+			continue
+		}
+		if t, err := parseTemplate(name, string(data)); err == nil {
+			tmpls[name] = t
+		}
+	}
+	activeTemplates = tmpls
+}
+
+// render executes the named template, falling back to the built-in one if
+// a custom template fails on this data.
+func render(name string, data any) string {
+	var sb strings.Builder
+	if err := activeTemplates[name].Execute(&sb, data); err == nil {
+		return sb.String()
+	}
+	sb.Reset()
+	defaultTemplates[name].Execute(&sb, data)
+	return sb.String()
+}
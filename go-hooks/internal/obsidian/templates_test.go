@@ -0,0 +1,56 @@
+package obsidian
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useTemplates loads templates from a temp dir holding files, restoring the
+// built-in templates after the test.
+func useTemplates(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	LoadTemplates(dir)
+	t.Cleanup(func() { activeTemplates = defaultTemplates })
+}
+
+// TestLoadTemplates_Override verifies custom files replace only their template
+// and can use every session field.
+func TestLoadTemplates_Override(t *testing.T) {
+	useTemplates(t, map[string]string{
+		"prompt.tmpl": "\r\n> [!question]- {{.Project}}@{{.GitBranch}} #{{.PromptNum}} ({{.Model}}, {{tokens .Tokens.OutputTokens}})\r\n{{callout .Text}}\r\n",
+	})
+
+	e := EntryInfo{
+		SessionInfo: SessionInfo{Project: "Coding", GitBranch: "main", PromptNum: 3, Model: "claude-opus-4-5",
+			Tokens: Usage{OutputTokens: 12300}},
+		Time: "13:50:22",
+		Text: "hi",
+	}
+	if got, want := FormatPromptEntry(e), "\n> [!question]- Coding@main #3 (claude-opus-4-5, 12.3k)\n> hi\n"; got != want {
+		t.Errorf("custom prompt: got %q, want %q", got, want)
+	}
+	if got, want := FormatResponseEntry(e), "\n> [!claude]- Claude (13:50:22)\n> hi\n\n---\n"; got != want {
+		t.Errorf("response should keep the built-in template: got %q, want %q", got, want)
+	}
+}
+
+// TestLoadTemplates_InvalidFallsBack verifies broken templates never lose entries.
+func TestLoadTemplates_InvalidFallsBack(t *testing.T) {
+	useTemplates(t, map[string]string{
+		"plan.tmpl":     "{{.Text", // parse error
+		"response.tmpl": "{{.NoSuchField}}",
+	})
+
+	e := EntryInfo{Time: "13:52:50", Text: "x"}
+	if got, want := FormatPlanEntry(e), "\n> [!plan]- Claude's Plan (13:52:50)\n> x\n\n---\n"; got != want {
+		t.Errorf("plan: got %q, want %q", got, want)
+	}
+	if got, want := FormatResponseEntry(e), "\n> [!claude]- Claude (13:52:50)\n> x\n\n---\n"; got != want {
+		t.Errorf("response: got %q, want %q", got, want)
+	}
+}
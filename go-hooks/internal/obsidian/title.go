@@ -0,0 +1,112 @@
+package obsidian
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// titleLen and slugLen cap session titles and file name slugs, in characters.
+const (
+	titleLen = 60
+	slugLen  = 40
+)
+
+// defaultHeadingPrefix starts the H1 of a session note that has no title yet.
+const defaultHeadingPrefix = "Claude Session - "
+
+var (
+	// titleStripper removes characters that would break a wikilink alias.
+	titleStripper = strings.NewReplacer("[", "", "]", "", "|", "", "^", "", "`", "")
+	headingRe     = regexp.MustCompile(`(?m)^# (.*)$`)
+)
+
+// SessionTitle condenses a prompt or a Claude Code summary into a short
+// single-line title: its first line of text, without Markdown markers, cut
+// on a word boundary. Returns "" if nothing usable remains.
+func SessionTitle(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimLeft(line, "#>-*+ \t")
+		line = strings.Join(strings.Fields(titleStripper.Replace(line)), " ")
+		if !strings.ContainsFunc(line, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+			continue
+		}
+		if utf8.RuneCountInString(line) <= titleLen {
+			return line
+		}
+		cut := line[:cutIndex(line, titleLen)]
+		if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+			cut = cut[:i]
+		}
+		return strings.TrimRight(cut, " ,;:.-") + "..."
+	}
+	return ""
+}
+
+// Slugify turns a title into a lowercase file name slug: letters and digits
+// kept, everything else collapsed to single hyphens.
+func Slugify(title string) string {
+	var sb strings.Builder
+	hyphen := false
+	n := 0
+	for _, r := range strings.ToLower(title) {
+		if n >= slugLen {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && sb.Len() > 0 {
+			sb.WriteByte('-')
+			hyphen = true
+		} else {
+			continue
+		}
+		n++
+	}
+	return strings.TrimRight(sb.String(), "-")
+}
+
+// yamlQuote renders s as a double-quoted YAML scalar.
+func yamlQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// unquoteYAML reverses yamlQuote; unquoted values are returned as is.
+func unquoteYAML(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// NoteTitle returns the title recorded in a session note's frontmatter.
+func NoteTitle(content string) string {
+	return unquoteYAML(FrontmatterField(content, "title"))
+}
+
+// SetTitle records title in the note's frontmatter and, if the H1 still shows
+// the previous title or the default heading, in the H1 as well.
+func SetTitle(content, title string) string {
+	old := NoteTitle(content)
+	content = SetFrontmatterField(content, "title", yamlQuote(title))
+
+	end := frontmatterEnd(content)
+	if end < 0 {
+		return content
+	}
+	loc := headingRe.FindStringSubmatchIndex(content[end:])
+	if loc == nil {
+		return content
+	}
+	heading := content[end+loc[2] : end+loc[3]]
+	if heading != old && !strings.HasPrefix(heading, defaultHeadingPrefix) {
+		return content // customized heading, leave it alone
+	}
+	return content[:end+loc[2]] + title + content[end+loc[3]:]
+}
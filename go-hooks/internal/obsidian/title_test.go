@@ -0,0 +1,107 @@
+package obsidian
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSessionTitle(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"can you fix the login redirect?", "can you fix the login redirect?"},
+		{"\n\n## Plan\n- step one", "Plan"},
+		{"look at [[Note|alias]] and `code`", "look at Notealias and code"},
+		{"  ---  \n> quoted   text\there", "quoted text here"},
+		{"Refactor the session state handling so that concurrent hooks never clobber each other's updates",
+			"Refactor the session state handling so that concurrent..."},
+		{"???", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := SessionTitle(tt.in); got != tt.want {
+			t.Errorf("SessionTitle(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Fix the login redirect?", "fix-the-login-redirect"},
+		{"  C:\\path/to: file*  ", "c-path-to-file"},
+		{"Café déjà vu", "café-déjà-vu"},
+		{strings.Repeat("abc ", 20), "abc-abc-abc-abc-abc-abc-abc-abc-abc-abc"},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.in); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestSetTitle verifies the title is quoted in frontmatter and replaces the
+// default or previous heading, but never a customized one.
+func TestSetTitle(t *testing.T) {
+	note := BuildFrontmatter(SessionInfo{Date: "2026-02-13", SessionID: "id", Project: "Coding", StartTime: "13:50"})
+
+	got := SetTitle(note, `Fix "C:\tmp" paths`)
+	if !strings.Contains(got, "project: Coding\nstart_time: 13:50\ntitle: \"Fix \\\"C:\\\\tmp\\\" paths\"\ntags:") {
+		t.Errorf("expected quoted title in frontmatter, got:\n%s", got)
+	}
+	if !strings.Contains(got, "\n# Fix \"C:\\tmp\" paths\n") {
+		t.Errorf("expected title heading, got:\n%s", got)
+	}
+	if NoteTitle(got) != `Fix "C:\tmp" paths` {
+		t.Errorf("NoteTitle round trip: got %q", NoteTitle(got))
+	}
+
+	got = SetTitle(got, "Second title")
+	if !strings.Contains(got, "\n# Second title\n") || strings.Count(got, "title:") != 1 {
+		t.Errorf("expected heading and field replaced, got:\n%s", got)
+	}
+
+	custom := strings.Replace(got, "# Second title", "# My own heading", 1)
+	if got := SetTitle(custom, "Third"); !strings.Contains(got, "# My own heading\n") {
+		t.Errorf("customized heading should be kept, got:\n%s", got)
+	}
+}
+
+// TestBuildFrontmatter_WithTitle verifies the title lands in frontmatter and H1.
+func TestBuildFrontmatter_WithTitle(t *testing.T) {
+	got := BuildFrontmatter(SessionInfo{Date: "2026-02-13", SessionID: "abc-123", Project: "Coding", StartTime: "13:50",
+		Title: "Fix login"})
+	want := "---\n" +
+		"date: 2026-02-13\n" +
+		"session_id: abc-123\n" +
+		"project: Coding\n" +
+		"title: \"Fix login\"\n" +
+		"start_time: 13:50\n" +
+		"tags:\n" +
+		"  - claude-session\n" +
+		"  - coding\n" +
+		"---\n" +
+		"\n# Fix login\n" +
+		"\n---\n"
+	if got != want {
+		t.Errorf("BuildFrontmatter (title) mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestRebuildDailyIndex_TitleAlias verifies titled sessions show their title in the link.
+func TestRebuildDailyIndex_TitleAlias(t *testing.T) {
+	setHome(t)
+	vault := t.TempDir()
+	dir := filepath.Join(vault, "Coding")
+	os.MkdirAll(dir, 0755)
+	note := BuildFrontmatter(SessionInfo{Date: "2026-02-12", SessionID: "t1", Project: "Coding", StartTime: "09:15",
+		Title: "Fix login"})
+	os.WriteFile(filepath.Join(dir, "2026-02-12_0915_fix-login.md"), []byte(note), 0644)
+
+	if err := RebuildDailyIndex(vault, "2026-02-12"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(vault, "2026-02-12.md"))
+	if !strings.Contains(string(data), "- [[Coding/2026-02-12_0915_fix-login|09:15 Fix login]]\n") {
+		t.Errorf("expected title alias, got:\n%s", data)
+	}
+}
@@ -0,0 +1,175 @@
+package obsidian
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/valentinclaes/claude-hooks/internal/redact"
+)
+
+var backtickRunRe = regexp.MustCompile("`{3,}")
+
+// toolFields is the subset of tool_input fields we know how to render.
+type toolFields struct {
+	FilePath     string `json:"file_path"`
+	NotebookPath string `json:"notebook_path"`
+	Command      string `json:"command"`
+	Pattern      string `json:"pattern"`
+	URL          string `json:"url"`
+	Query        string `json:"query"`
+	Description  string `json:"description"`
+	OldString    string `json:"old_string"`
+	NewString    string `json:"new_string"`
+	Content      string `json:"content"`
+	Edits        []struct {
+		OldString string `json:"old_string"`
+		NewString string `json:"new_string"`
+	} `json:"edits"`
+}
+
+// bashResponse is the tool_response shape of the Bash tool.
+type bashResponse struct {
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+}
+
+// FormatToolEntry formats a tool call as a collapsed Obsidian callout.
+// The title names the tool and its main argument (file, command, pattern);
+// the body shows what changed or ran. response may be empty (PreToolUse).
+// limit caps each rendered piece (command output, written file content, raw
+// JSON input); <= 0 means no limit. Secrets are redacted before anything is
+// cut, so a secret straddling the limit cannot leak as a prefix.
+func FormatToolEntry(timeStr, toolName string, input, response json.RawMessage, limit int, redactor *redact.Redactor) string {
+	var f toolFields
+	json.Unmarshal(input, &f)
+	f.redact(redactor)
+
+	title := "> [!tool]- " + toolName
+	if h := toolHeadline(toolName, f); h != "" {
+		title += ": ``" + h + "``"
+	}
+	title += " (" + timeStr + ")"
+
+	body := toolBody(toolName, f, input, response, limit, redactor)
+	if body == "" {
+		return "\n" + title + "\n"
+	}
+	return fmt.Sprintf("\n%s\n%s\n", title, FormatCalloutContent(body))
+}
+
+// redact masks secrets in every rendered field.
+func (f *toolFields) redact(r *redact.Redactor) {
+	for _, s := range []*string{&f.FilePath, &f.NotebookPath, &f.Command, &f.Pattern,
+		&f.URL, &f.Query, &f.Description, &f.OldString, &f.NewString, &f.Content} {
+		*s = r.Redact(*s)
+	}
+	for i := range f.Edits {
+		f.Edits[i].OldString = r.Redact(f.Edits[i].OldString)
+		f.Edits[i].NewString = r.Redact(f.Edits[i].NewString)
+	}
+}
+
+// toolHeadline returns a short single-line description of the call.
+func toolHeadline(toolName string, f toolFields) string {
+	var h string
+	switch {
+	case f.FilePath != "":
+		h = f.FilePath
+	case f.NotebookPath != "":
+		h = f.NotebookPath
+	case f.Command != "":
+		h = f.Command
+	case f.Pattern != "":
+		h = f.Pattern
+	case f.URL != "":
+		h = f.URL
+	case f.Query != "":
+		h = f.Query
+	case f.Description != "":
+		h = f.Description
+	}
+	if i := strings.IndexByte(h, '\n'); i >= 0 {
+		h = h[:i] + " ..."
+	}
+	if utf8.RuneCountInString(h) > 120 {
+		h = h[:cutIndex(h, 120)] + "..."
+	}
+	return strings.ReplaceAll(h, "`", "'")
+}
+
+// toolBody renders the details of a tool call, or "" if the headline says it all.
+func toolBody(toolName string, f toolFields, input, response json.RawMessage, limit int, redactor *redact.Redactor) string {
+	switch toolName {
+	case "Bash":
+		body := codeFence("bash", f.Command)
+		var r bashResponse
+		if json.Unmarshal(response, &r) == nil {
+			out := strings.TrimSpace(strings.TrimSpace(r.Stdout) + "\n" + strings.TrimSpace(r.Stderr))
+			out = redactor.Redact(out)
+			if out != "" {
+				body += "\n" + codeFence("", truncateTo(out, limit, Truncate))
+			}
+		}
+		return body
+	case "Edit":
+		return diffFence(f.OldString, f.NewString, limit)
+	case "MultiEdit":
+		var parts []string
+		for _, e := range f.Edits {
+			parts = append(parts, diffFence(e.OldString, e.NewString, limit))
+		}
+		return strings.Join(parts, "\n")
+	case "Write":
+		return codeFence("", truncateTo(f.Content, limit, TruncateSimple))
+	case "Read", "Glob", "Grep", "LS", "WebFetch", "WebSearch", "TodoWrite":
+		return ""
+	}
+	if len(input) == 0 {
+		return ""
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, input); err != nil {
+		return ""
+	}
+	return codeFence("json", truncateTo(redactor.Redact(compact.String()), limit, TruncateSimple))
+}
+
+// diffFence renders an edit as a diff block of removed and added lines.
+func diffFence(oldText, newText string, limit int) string {
+	var lines []string
+	if oldText != "" {
+		for _, l := range strings.Split(oldText, "\n") {
+			lines = append(lines, "- "+l)
+		}
+	}
+	if newText != "" {
+		for _, l := range strings.Split(newText, "\n") {
+			lines = append(lines, "+ "+l)
+		}
+	}
+	return codeFence("diff", truncateTo(strings.Join(lines, "\n"), limit, TruncateSimple))
+}
+
+// truncateTo applies truncate unless limit is <= 0 (no limit).
+func truncateTo(text string, limit int, truncate func(string, int) string) string {
+	if limit <= 0 {
+		return text
+	}
+	return truncate(text, limit)
+}
+
+// codeFence wraps text in a fenced code block whose fence is longer than any
+// backtick run inside text, so embedded fences cannot close it early.
+func codeFence(lang, text string) string {
+	fence := "```"
+	for _, run := range backtickRunRe.FindAllString(text, -1) {
+		if len(run) >= len(fence) {
+			fence = strings.Repeat("`", len(run)+1)
+		}
+	}
+	return fence + lang + "\n" + text + "\n" + fence
+}
@@ -0,0 +1,90 @@
+package obsidian
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/valentinclaes/claude-hooks/internal/config"
+	"github.com/valentinclaes/claude-hooks/internal/redact"
+)
+
+// TestFormatToolEntry_Bash verifies command and output are rendered in fences.
+func TestFormatToolEntry_Bash(t *testing.T) {
+	got := FormatToolEntry("13:50:22", "Bash",
+		[]byte(`{"command":"go test ./...","description":"Run tests"}`),
+		[]byte(`{"stdout":"ok\n","stderr":"","interrupted":false}`), 2000, nil)
+	want := "\n> [!tool]- Bash: ``go test ./...`` (13:50:22)\n" +
+		"> ```bash\n" +
+		"> go test ./...\n" +
+		"> ```\n" +
+		"> ```\n" +
+		"> ok\n" +
+		"> ```\n"
+	if got != want {
+		t.Errorf("FormatToolEntry Bash mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestFormatToolEntry_Edit verifies edits are rendered as a diff.
+func TestFormatToolEntry_Edit(t *testing.T) {
+	got := FormatToolEntry("13:50:22", "Edit",
+		[]byte(`{"file_path":"/src/main.go","old_string":"a := 1","new_string":"a := 2"}`), nil, 2000, nil)
+	want := "\n> [!tool]- Edit: ``/src/main.go`` (13:50:22)\n" +
+		"> ```diff\n" +
+		"> - a := 1\n" +
+		"> + a := 2\n" +
+		"> ```\n"
+	if got != want {
+		t.Errorf("FormatToolEntry Edit mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestFormatToolEntry_ReadHasNoBody verifies read-only tools render as a single line.
+func TestFormatToolEntry_ReadHasNoBody(t *testing.T) {
+	got := FormatToolEntry("13:50:22", "Read", []byte(`{"file_path":"/src/main.go"}`), []byte(`{}`), 2000, nil)
+	want := "\n> [!tool]- Read: ``/src/main.go`` (13:50:22)\n"
+	if got != want {
+		t.Errorf("FormatToolEntry Read mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestFormatToolEntry_UnknownTool verifies unknown tools fall back to compact JSON.
+func TestFormatToolEntry_UnknownTool(t *testing.T) {
+	got := FormatToolEntry("13:50:22", "mcp__db__query", []byte(`{ "sql": "select 1" }`), nil, 2000, nil)
+	if !strings.Contains(got, "> ```json\n> {\"sql\":\"select 1\"}\n> ```\n") {
+		t.Errorf("expected compact JSON body, got:\n%s", got)
+	}
+}
+
+// TestFormatToolEntry_Limit verifies output is cut at the given limit.
+func TestFormatToolEntry_Limit(t *testing.T) {
+	got := FormatToolEntry("13:50:22", "Write",
+		[]byte(`{"file_path":"/a.txt","content":"0123456789"}`), nil, 4, nil)
+	if !strings.Contains(got, "> 0123\n> \n> ... (truncated)\n") {
+		t.Errorf("expected content truncated to 4 bytes, got:\n%s", got)
+	}
+}
+
+// TestFormatToolEntry_RedactsBeforeLimit verifies a secret cut by the limit
+// is redacted whole instead of leaking as a prefix.
+func TestFormatToolEntry_RedactsBeforeLimit(t *testing.T) {
+	token := "ghp_" + strings.Repeat("abcdefghij", 4)
+	got := FormatToolEntry("13:50:22", "Bash",
+		[]byte(`{"command":"cat .env"}`),
+		[]byte(`{"stdout":"token: `+token+`\n","stderr":""}`), 20, redact.New(config.Redact{Enabled: true}))
+	if strings.Contains(got, "ghp_") {
+		t.Errorf("secret prefix leaked:\n%s", got)
+	}
+	if !strings.Contains(got, "[REDACTED:") {
+		t.Errorf("expected redacted output, got:\n%s", got)
+	}
+}
+
+// TestCodeFence_NestedBackticks verifies the fence outgrows embedded fences.
+func TestCodeFence_NestedBackticks(t *testing.T) {
+	got := codeFence("", "```go\nx\n```")
+	want := "````\n```go\nx\n```\n````"
+	if got != want {
+		t.Errorf("codeFence: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+package obsidian
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const zeroWidthJoiner = '\u200d'
+
+// Truncate truncates text to maxLen characters and appends a note with the
+// total character count.
+func Truncate(text string, maxLen int) string {
+	text = validUTF8(text)
+	n := utf8.RuneCountInString(text)
+	if n <= maxLen {
+		return text
+	}
+	return cutText(text, maxLen) + fmt.Sprintf("\n\n... (truncated, %d chars total)", n)
+}
+
+// TruncateSimple truncates text to maxLen characters with a simple
+// "... (truncated)" suffix.
+func TruncateSimple(text string, maxLen int) string {
+	text = validUTF8(text)
+	if utf8.RuneCountInString(text) <= maxLen {
+		return text
+	}
+	return cutText(text, maxLen) + "\n\n... (truncated)"
+}
+
+func validUTF8(text string) string {
+	if utf8.ValidString(text) {
+		return text
+	}
+	return strings.ToValidUTF8(text, "\uFFFD")
+}
+
+// cutText returns at most maxLen characters of text, cut on a grapheme
+// boundary and with any code fence left open by the cut closed again.
+func cutText(text string, maxLen int) string {
+	return closeFence(text[:cutIndex(text, maxLen)])
+}
+
+// cutIndex returns the byte index of the end of the first maxLen characters
+// of text, backed off so that a grapheme cluster (a base character with its
+// combining marks, an emoji ZWJ sequence, a flag) is never split.
+func cutIndex(text string, maxLen int) int {
+	i := 0
+	for n := 0; n < maxLen && i < len(text); n++ {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	for i > 0 && i < len(text) {
+		next, _ := utf8.DecodeRuneInString(text[i:])
+		prev, size := utf8.DecodeLastRuneInString(text[:i])
+		if !extendsCluster(next) && prev != zeroWidthJoiner && !(prev == '\r' && next == '\n') &&
+			!(isRegionalIndicator(next) && oddRegionalIndicators(text[:i])) {
+			break
+		}
+		i -= size
+	}
+	return i
+}
+
+// extendsCluster reports whether r attaches to the character before it.
+func extendsCluster(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Variation_Selector) ||
+		r == zeroWidthJoiner ||
+		(r >= 0x1F3FB && r <= 0x1F3FF) || // emoji skin tone modifiers
+		(r >= 0xE0020 && r <= 0xE007F) // emoji tag sequences (subdivision flags)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// oddRegionalIndicators reports whether text ends in an odd run of regional
+// indicators, i.e. with the first half of a flag.
+func oddRegionalIndicators(text string) bool {
+	n := 0
+	for len(text) > 0 {
+		r, size := utf8.DecodeLastRuneInString(text)
+		if !isRegionalIndicator(r) {
+			break
+		}
+		n++
+		text = text[:len(text)-size]
+	}
+	return n%2 == 1
+}
+
+// closeFence appends a closing fence if text ends inside a fenced code
+// block, so the rest of the note is not rendered as code.
+func closeFence(text string) string {
+	open := ""
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if len(line)-len(trimmed) > 3 {
+			continue // indented code, not a fence
+		}
+		run := fenceRun(trimmed)
+		switch {
+		case run == "":
+		case open == "":
+			open = run
+		case run[0] == open[0] && len(run) >= len(open) && strings.TrimSpace(trimmed[len(run):]) == "":
+			open = ""
+		}
+	}
+	if open == "" {
+		return text
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return text + open
+}
+
+// fenceRun returns the run of three or more backticks or tildes starting
+// line, or "".
+func fenceRun(line string) string {
+	if line == "" || (line[0] != '`' && line[0] != '~') {
+		return ""
+	}
+	n := 1
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	if n < 3 {
+		return ""
+	}
+	return line[:n]
+}
@@ -0,0 +1,81 @@
+package obsidian
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestTruncate_CountsCharacters verifies limits and totals are in characters.
+func TestTruncate_CountsCharacters(t *testing.T) {
+	text := strings.Repeat("é", 6)
+	if got := Truncate(text, 6); got != text {
+		t.Errorf("6 chars should fit a limit of 6, got %q", got)
+	}
+	want := "éééé\n\n... (truncated, 6 chars total)"
+	if got := Truncate(text, 4); got != want {
+		t.Errorf("Truncate: got %q, want %q", got, want)
+	}
+}
+
+// TestCutIndex_GraphemeBoundaries verifies cuts back off to the start of a
+// cluster instead of splitting it.
+func TestCutIndex_GraphemeBoundaries(t *testing.T) {
+	tests := []struct {
+		name, text string
+		maxLen     int
+		want       string
+	}{
+		{"combining mark", "aéb", 2, "a"},
+		{"zwj sequence", "a👩‍💻b", 3, "a"},
+		{"skin tone", "a👍🏽b", 2, "a"},
+		{"variation selector", "a❤️b", 2, "a"},
+		{"flag", "a🇧🇪b", 2, "a"},
+		{"second flag", "🇧🇪🇫🇷", 3, "🇧🇪"},
+		{"crlf", "a\r\nb", 2, "a"},
+		{"plain", "héllo", 3, "hél"},
+	}
+	for _, tt := range tests {
+		if got := tt.text[:cutIndex(tt.text, tt.maxLen)]; got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestTruncate_ClosesFence verifies a cut inside a code block closes it.
+func TestTruncate_ClosesFence(t *testing.T) {
+	text := "intro\n````go\nfunc main() {}\n````\n\n~~~\nline one\nline two\n~~~\n"
+	got := TruncateSimple(text, 48)
+	want := "intro\n````go\nfunc main() {}\n````\n\n~~~\nline one\nl\n~~~\n\n... (truncated)"
+	if got != want {
+		t.Errorf("TruncateSimple: got %q, want %q", got, want)
+	}
+
+	// A cut after the block closed adds nothing
+	got = TruncateSimple(text, 33)
+	want = "intro\n````go\nfunc main() {}\n````\n\n\n... (truncated)"
+	if got != want {
+		t.Errorf("TruncateSimple after fence: got %q, want %q", got, want)
+	}
+}
+
+func FuzzTruncate(f *testing.F) {
+	for _, seed := range []string{
+		"hello", "aéb", "a👩‍💻b", "🇧🇪🇫🇷", "```go\nx\n", "日本語のテキスト", "\xff\xfe",
+	} {
+		f.Add(seed, 3)
+	}
+	f.Fuzz(func(t *testing.T, text string, maxLen int) {
+		if maxLen < 0 || maxLen > 1<<16 {
+			t.Skip()
+		}
+		for _, got := range []string{Truncate(text, maxLen), TruncateSimple(text, maxLen)} {
+			if !utf8.ValidString(got) {
+				t.Fatalf("invalid UTF-8 output for %q (max %d): %q", text, maxLen, got)
+			}
+		}
+		if i := cutIndex(text, maxLen); utf8.RuneCountInString(text[:i]) > maxLen {
+			t.Fatalf("cut of %q keeps more than %d chars", text, maxLen)
+		}
+	})
+}
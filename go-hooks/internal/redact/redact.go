@@ -0,0 +1,178 @@
+// Package redact masks secrets (API keys, tokens, private keys, passwords)
+// in text before it is written to the vault, replacing each hit with
+// [REDACTED:type].
+package redact
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/valentinclaes/claude-hooks/internal/config"
+)
+
+// rule is one detector. If the regex has a capture group, only the first
+// group is replaced, so "password=hunter2" keeps its key. If valid is set,
+// matches it rejects are left alone.
+type rule struct {
+	name  string
+	re    *regexp.Regexp
+	valid func(match string) bool
+}
+
+// builtins run in order: multi-line and well-known formats first, generic
+// key=value patterns after, so the most specific type name wins.
+var builtins = []rule{
+	{"private-key", regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----.*?-----END [A-Z0-9 ]*PRIVATE KEY-----`), nil},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[A-Z0-9]{16}\b`), nil},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`), nil},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`), nil},
+	{"slack-token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), nil},
+	{"api-key", regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`), nil},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`), nil},
+	{"bearer-token", regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{16,}=*)`), nil},
+	{"connection-string", regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9+.-]*://[^\s:/@]+:([^\s@/]+)@`), nil},
+	{"password", secretAssignment, plausibleSecret},
+}
+
+// secretAssignment matches key: value or key=value where the key is, or ends
+// in, a secret-like word. The word must be a whole segment of the key, so
+// DB_PASSWORD and X-Api-Key match but max_tokens and tokenizer do not; a
+// bare "token" only counts as the last segment of a longer key (AUTH_TOKEN).
+var secretAssignment = regexp.MustCompile(`(?i)\b(?:(?:[A-Za-z0-9]+[_-])*(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?key|secret[_-]?key|private[_-]?key)|(?:[A-Za-z0-9]+[_-])+token)\b["']?[ \t]*[:=][ \t]*["']?([^\s"',;]{4,})`)
+
+// notSecretValues are type names and literals that follow a key in code.
+var notSecretValues = map[string]bool{
+	"string": true, "number": true, "boolean": true, "bool": true, "int": true,
+	"integer": true, "float": true, "str": true, "bytes": true, "any": true,
+	"unknown": true, "object": true, "null": true, "nil": true, "none": true,
+	"undefined": true, "true": true, "false": true,
+}
+
+var (
+	numberRe    = regexp.MustCompile(`^[0-9][0-9._]*$`)
+	plainWordRe = regexp.MustCompile(`^[a-z]+$`)
+)
+
+// plausibleSecret rejects assigned values that are plainly not secrets:
+// numbers, paths, type names and single lowercase words.
+func plausibleSecret(value string) bool {
+	switch {
+	case numberRe.MatchString(value),
+		strings.HasPrefix(value, "/"), strings.HasPrefix(value, "~/"), strings.HasPrefix(value, "./"),
+		notSecretValues[strings.ToLower(strings.TrimRight(value, "[]?|"))],
+		plainWordRe.MatchString(value):
+		return false
+	}
+	return true
+}
+
+// highEntropyCandidate matches long runs of key-like characters, which are
+// then kept only if their Shannon entropy is high.
+var highEntropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_=-]{32,}`)
+
+// minEntropy is in bits per character. Hex digests (max 4.0) and ordinary
+// identifiers stay below it; random base64/base62 secrets exceed it.
+const minEntropy = 4.5
+
+// Redactor applies the built-in detectors plus user-defined patterns.
+// A nil *Redactor redacts nothing.
+type Redactor struct {
+	rules []rule
+}
+
+// New returns a Redactor for cfg, or nil if redaction is disabled.
+// User patterns that fail to compile are skipped.
+func New(cfg config.Redact) *Redactor {
+	if !cfg.Enabled {
+		return nil
+	}
+	r := &Redactor{rules: append([]rule(nil), builtins...)}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			continue
+		}
+		name := p.Name
+		if name == "" {
+			name = "custom"
+		}
+		r.rules = append(r.rules, rule{name: name, re: re})
+	}
+	return r
+}
+
+// Redact returns text with every detected secret replaced by [REDACTED:type].
+func (r *Redactor) Redact(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	for _, ru := range r.rules {
+		text = ru.apply(text)
+	}
+	return highEntropyCandidate.ReplaceAllStringFunc(text, func(s string) string {
+		if isHighEntropy(s) {
+			return placeholder("high-entropy")
+		}
+		return s
+	})
+}
+
+const placeholderPrefix = "[REDACTED:"
+
+func placeholder(name string) string {
+	return placeholderPrefix + name + "]"
+}
+
+// apply replaces each match of the rule (or its first group) in text.
+func (ru rule) apply(text string) string {
+	matches := ru.re.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if strings.HasPrefix(text[start:end], placeholderPrefix) {
+			continue // already redacted by an earlier rule
+		}
+		if ru.valid != nil && !ru.valid(text[start:end]) {
+			continue
+		}
+		sb.WriteString(text[last:start])
+		sb.WriteString(placeholder(ru.name))
+		last = end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// isHighEntropy reports whether s looks like a random secret: it must mix
+// letters and digits and have high per-character entropy.
+func isHighEntropy(s string) bool {
+	var hasLetter, hasDigit bool
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+		switch {
+		case c >= '0' && c <= '9':
+			hasDigit = true
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			hasLetter = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return false
+	}
+	var entropy float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= minEntropy
+}
@@ -0,0 +1,43 @@
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+// Event kinds recorded in SessionData.Events.
+const (
+	EventPrompt   = "prompt"
+	EventResponse = "response"
+)
+
+// Event is a timestamped hook event of a session.
+type Event struct {
+	Time time.Time `json:"t"`
+	Kind string    `json:"kind"`
+}
+
+// AddEvent records a hook event of the given kind.
+func (sd *SessionData) AddEvent(kind string, t time.Time) {
+	sd.Events = append(sd.Events, Event{Time: t, Kind: kind})
+}
+
+// ActiveDuration sums the intervals between consecutive events. The time
+// from a prompt to its response is Claude working and always counts; any
+// other gap longer than idle is the user away and is skipped. An idle of
+// zero or less counts every gap.
+func ActiveDuration(events []Event, idle time.Duration) time.Duration {
+	sorted := append([]Event(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	var active time.Duration
+	for i := 1; i < len(sorted); i++ {
+		prev, cur := sorted[i-1], sorted[i]
+		gap := cur.Time.Sub(prev.Time)
+		working := prev.Kind == EventPrompt && cur.Kind == EventResponse
+		if working || idle <= 0 || gap <= idle {
+			active += gap
+		}
+	}
+	return active
+}
@@ -0,0 +1,32 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+// TestActiveDuration verifies idle gaps are skipped unless Claude was
+// working on a prompt.
+func TestActiveDuration(t *testing.T) {
+	at := func(min int) time.Time {
+		return time.Date(2026, 2, 13, 9, 0, 0, 0, time.UTC).Add(time.Duration(min) * time.Minute)
+	}
+	sd := &SessionData{}
+	sd.AddEvent(EventPrompt, at(0))
+	sd.AddEvent(EventResponse, at(30)) // long agentic run: counts
+	sd.AddEvent(EventPrompt, at(35))   // 5min reading the answer: counts
+	sd.AddEvent(EventResponse, at(37))
+	sd.AddEvent(EventPrompt, at(240)) // lunch: skipped
+	sd.AddEvent(EventPrompt, at(300)) // interrupted, no response: skipped
+	sd.AddEvent(EventResponse, at(310))
+
+	if got := ActiveDuration(sd.Events, 15*time.Minute); got != 47*time.Minute {
+		t.Errorf("ActiveDuration = %v, want 47m", got)
+	}
+	if got := ActiveDuration(sd.Events, 0); got != 310*time.Minute {
+		t.Errorf("ActiveDuration without cutoff = %v, want wall clock 310m", got)
+	}
+	if got := ActiveDuration(nil, 15*time.Minute); got != 0 {
+		t.Errorf("ActiveDuration(nil) = %v", got)
+	}
+}
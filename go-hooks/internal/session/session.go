@@ -1,34 +1,163 @@
 package session
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/valentinclaes/claude-hooks/internal/atomicfile"
+	"github.com/valentinclaes/claude-hooks/internal/config"
+	"github.com/valentinclaes/claude-hooks/internal/filelock"
 )
 
-// SessionData holds the session-to-file mapping.
+// schemaVersion is the current version of the state file format.
+// Version 0 is the legacy two-line temp file (filepath\npromptNum).
+const schemaVersion = 1
+
+// lockWait is how long a hook waits for another hook working on the same
+// session before giving up on the lock.
+const lockWait = 5 * time.Second
+
+// staleAfter is how long a session can go without events before its state
+// is removed by CleanupStale.
+const staleAfter = 7 * 24 * time.Hour
+
+// SessionData is the persisted state of a logged session.
 type SessionData struct {
-	FilePath  string
-	PromptNum int
+	Version   int       `json:"version"`
+	FilePath  string    `json:"file_path"`
+	PromptNum int       `json:"prompt_num"`
+	StartTime time.Time `json:"start_time"`
+	Project   string    `json:"project,omitempty"`
+	Title     string    `json:"title,omitempty"`
+	Cwd       string    `json:"cwd,omitempty"`
+	GitBranch string    `json:"git_branch,omitempty"`
+	// Source is how the session started, from the SessionStart hook.
+	Source string `json:"source,omitempty"`
+	// LastUUID is the uuid of the last transcript entry already logged.
+	LastUUID string `json:"last_uuid,omitempty"`
+	// Offset is the transcript byte offset up to which entries were logged.
+	Offset int64 `json:"transcript_offset"`
+	// CompactUUID is the uuid of the last compaction summary logged.
+	CompactUUID string `json:"compact_uuid,omitempty"`
+	// Subagents holds the root uuids of the latest sub-agent runs logged.
+	Subagents []string  `json:"subagents,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Tokens    Tokens    `json:"tokens"`
+	LastEvent time.Time `json:"last_event"`
+	// Events are the prompt and response times, for the active duration.
+	Events []Event `json:"events,omitempty"`
+}
+
+// Tokens is the running token usage and estimated cost of a session.
+type Tokens struct {
+	Input         int64   `json:"input"`
+	Output        int64   `json:"output"`
+	CacheRead     int64   `json:"cache_read"`
+	CacheCreation int64   `json:"cache_creation"`
+	CostUSD       float64 `json:"cost_usd"`
 }
 
-func mapPath(sessionID string) string {
+// Dir returns the per-user directory holding session state files
+// (~/.claude/hooks/state). Unlike the temp dir it is not wiped by cleanup
+// tools, so live sessions keep their note across reboots.
+func Dir() string {
+	hooksDir := config.HooksDir()
+	if hooksDir == "" {
+		return filepath.Join(os.TempDir(), "claude-hooks-state")
+	}
+	return filepath.Join(hooksDir, "state")
+}
+
+func statePath(sessionID string) string {
+	return filepath.Join(Dir(), sessionID+".json")
+}
+
+// legacyPath is where schema version 0 kept the session mapping.
+func legacyPath(sessionID string) string {
 	return filepath.Join(os.TempDir(), "claude_session_"+sessionID+".txt")
 }
 
-// Read reads the session mapping file. Returns nil if not found.
+// Lock takes the session's exclusive lock, serializing updates to its state
+// and note across concurrent hook processes (parallel sub-agents, a prompt
+// racing a Stop). The returned func releases it and is safe to call more than
+// once, so it can be both deferred and called early. If the lock cannot be taken
+// within lockWait, an error is returned along with a no-op release, so
+// callers may proceed unlocked rather than block Claude.
+func Lock(sessionID string) (func(), error) {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return func() {}, err
+	}
+	l, err := filelock.Acquire(filepath.Join(Dir(), sessionID+".lock"), lockWait)
+	if err != nil {
+		return func() {}, err
+	}
+	var once sync.Once
+	return func() { once.Do(func() { l.Unlock() }) }, nil
+}
+
+// Read reads the session state, migrating a legacy temp file if that is all
+// there is. Returns nil if the session is unknown.
 func Read(sessionID string) (*SessionData, error) {
-	data, err := os.ReadFile(mapPath(sessionID))
+	data, err := os.ReadFile(statePath(sessionID))
+	if os.IsNotExist(err) {
+		return migrateLegacy(sessionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sd SessionData
+	if err := json.Unmarshal(data, &sd); err != nil {
+		return nil, err
+	}
+	if sd.Version > schemaVersion {
+		return nil, fmt.Errorf("session state version %d is newer than supported %d", sd.Version, schemaVersion)
+	}
+	return &sd, nil
+}
+
+// Write stores the session state, stamping the schema version and the time
+// of this event. The file is replaced atomically, so a hook killed mid-write
+// leaves the previous state rather than a truncated one.
+func Write(sessionID string, sd *SessionData) error {
+	sd.Version = schemaVersion
+	sd.LastEvent = time.Now()
+	data, err := json.MarshalIndent(sd, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(statePath(sessionID), data, 0644)
+}
+
+// Remove deletes the session state.
+func Remove(sessionID string) error {
+	err := os.Remove(statePath(sessionID))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// migrateLegacy converts a version 0 temp file (filepath\npromptNum, with
+// optional lastUUID and offset lines) into the current format.
+func migrateLegacy(sessionID string) (*SessionData, error) {
+	path := legacyPath(sessionID)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
-	lines := strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)
+	lines := strings.SplitN(strings.TrimSpace(string(data)), "\n", 4)
 	if len(lines) < 2 {
 		return nil, fmt.Errorf("invalid session map format")
 	}
@@ -36,30 +165,59 @@ func Read(sessionID string) (*SessionData, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &SessionData{FilePath: strings.TrimSpace(lines[0]), PromptNum: num}, nil
-}
+	sd := &SessionData{FilePath: strings.TrimSpace(lines[0]), PromptNum: num}
+	if len(lines) > 2 {
+		sd.LastUUID = strings.TrimSpace(lines[2])
+	}
+	if len(lines) > 3 {
+		sd.Offset, _ = strconv.ParseInt(strings.TrimSpace(lines[3]), 10, 64)
+	}
+	if info, err := os.Stat(path); err == nil {
+		sd.StartTime = info.ModTime()
+	}
 
-// Write writes the session mapping file (filepath\npromptNum, UTF-8 no BOM).
-func Write(sessionID, filePath string, promptNum int) error {
-	content := filePath + "\n" + strconv.Itoa(promptNum)
-	return os.WriteFile(mapPath(sessionID), []byte(content), 0644)
+	if err := Write(sessionID, sd); err != nil {
+		return sd, nil // still usable, retry migration next time
+	}
+	os.Remove(path)
+	return sd, nil
 }
 
-// CleanupStale removes session temp files older than 24 hours.
+// CleanupStale removes session state and lock files untouched for
+// staleAfter, and legacy temp files older than 24 hours.
 func CleanupStale() {
-	pattern := filepath.Join(os.TempDir(), "claude_session_*.txt")
+	for _, m := range olderThan(filepath.Join(Dir(), "*.json"), time.Now().Add(-staleAfter)) {
+		os.Remove(m)
+		os.Remove(strings.TrimSuffix(m, ".json") + ".lock")
+	}
+	// Ended sessions leave their lock file behind. flock does not touch a
+	// lock file's mtime, so one of a live session may look old; only remove
+	// those without a state file.
+	for _, m := range olderThan(filepath.Join(Dir(), "*.lock"), time.Now().Add(-staleAfter)) {
+		if _, err := os.Stat(strings.TrimSuffix(m, ".lock") + ".json"); os.IsNotExist(err) {
+			os.Remove(m)
+		}
+	}
+	for _, m := range olderThan(filepath.Join(os.TempDir(), "claude_session_*.txt"), time.Now().Add(-24*time.Hour)) {
+		os.Remove(m)
+	}
+}
+
+// olderThan returns the files matching pattern last modified before cutoff.
+func olderThan(pattern string, cutoff time.Time) []string {
 	matches, err := filepath.Glob(pattern)
 	if err != nil {
-		return
+		return nil
 	}
-	cutoff := time.Now().Add(-24 * time.Hour)
+	var old []string
 	for _, m := range matches {
 		info, err := os.Stat(m)
 		if err != nil {
 			continue
 		}
 		if info.ModTime().Before(cutoff) {
-			os.Remove(m)
+			old = append(old, m)
 		}
 	}
+	return old
 }
@@ -0,0 +1,231 @@
+package session
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// setHome points the user home (and with it the state dir) at a temp dir.
+func setHome(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("USERPROFILE", dir) // os.UserHomeDir() reads this on Windows
+	t.Setenv("HOME", dir)        // os.UserHomeDir() reads this on Unix
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	return dir
+}
+
+func TestRead_Unknown(t *testing.T) {
+	setHome(t)
+	sd, err := Read("does-not-exist")
+	if sd != nil || err != nil {
+		t.Fatalf("expected nil, nil for unknown session, got %+v, %v", sd, err)
+	}
+}
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	home := setHome(t)
+	start := time.Date(2026, 2, 13, 23, 30, 0, 0, time.UTC)
+	in := &SessionData{
+		FilePath:  "/vault/Coding/2026-02-13_2330.md",
+		PromptNum: 3,
+		StartTime: start,
+		Project:   "Coding",
+		Cwd:       "/work/coding",
+		GitBranch: "main",
+		LastUUID:  "u42",
+		Offset:    1234,
+		Model:     "claude-sonnet-4-5",
+		Tokens:    Tokens{Input: 10, Output: 20, CacheRead: 30, CacheCreation: 40, CostUSD: 0.5},
+	}
+	if err := Write("s1", in); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".claude", "hooks", "state", "s1.json")); err != nil {
+		t.Fatalf("expected state file under ~/.claude/hooks/state: %v", err)
+	}
+
+	got, err := Read("s1")
+	if err != nil || got == nil {
+		t.Fatalf("Read: %+v, %v", got, err)
+	}
+	if got.Version != schemaVersion || got.LastEvent.IsZero() {
+		t.Errorf("expected version and last event stamped, got %+v", got)
+	}
+	if got.FilePath != in.FilePath || got.PromptNum != 3 || !got.StartTime.Equal(start) ||
+		got.Project != "Coding" || got.Cwd != "/work/coding" || got.GitBranch != "main" || got.LastUUID != "u42" ||
+		got.Offset != 1234 || got.Model != in.Model || got.Tokens != in.Tokens {
+		t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", got, in)
+	}
+}
+
+func TestRead_MigratesLegacyTempFile(t *testing.T) {
+	setHome(t)
+	sid := "legacy-" + t.Name()
+	legacy := legacyPath(sid)
+	os.WriteFile(legacy, []byte("/vault/Coding/2026-02-13_1350.md\n7"), 0644)
+	defer os.Remove(legacy)
+
+	sd, err := Read(sid)
+	if err != nil || sd == nil {
+		t.Fatalf("Read: %+v, %v", sd, err)
+	}
+	if sd.FilePath != "/vault/Coding/2026-02-13_1350.md" || sd.PromptNum != 7 {
+		t.Errorf("migrated data mismatch: %+v", sd)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("legacy temp file should be removed after migration")
+	}
+	if _, err := os.Stat(statePath(sid)); err != nil {
+		t.Errorf("expected migrated state file: %v", err)
+	}
+}
+
+func TestRead_RejectsNewerVersion(t *testing.T) {
+	setHome(t)
+	os.MkdirAll(Dir(), 0755)
+	os.WriteFile(statePath("future"), []byte(`{"version": 99, "file_path": "x"}`), 0644)
+	if _, err := Read("future"); err == nil {
+		t.Error("expected error for newer schema version")
+	}
+}
+
+func TestCleanupStale(t *testing.T) {
+	setHome(t)
+	Write("fresh", &SessionData{FilePath: "a"})
+	Write("stale", &SessionData{FilePath: "b"})
+	old := time.Now().Add(-(staleAfter + time.Hour))
+	os.Chtimes(statePath("stale"), old, old)
+
+	CleanupStale()
+
+	if sd, _ := Read("fresh"); sd == nil {
+		t.Error("fresh session should survive cleanup")
+	}
+	if sd, _ := Read("stale"); sd != nil {
+		t.Error("stale session should be removed")
+	}
+}
+
+// TestCleanupStale_LockFiles verifies old lock files are only removed once
+// their session has no state left.
+func TestCleanupStale_LockFiles(t *testing.T) {
+	setHome(t)
+	Write("live", &SessionData{FilePath: "a"})
+	old := time.Now().Add(-(staleAfter + time.Hour))
+	for _, id := range []string{"live", "ended"} {
+		unlock, err := Lock(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unlock()
+		os.Chtimes(filepath.Join(Dir(), id+".lock"), old, old)
+	}
+
+	CleanupStale()
+
+	if _, err := os.Stat(filepath.Join(Dir(), "live.lock")); err != nil {
+		t.Error("lock file of a live session must be kept")
+	}
+	if _, err := os.Stat(filepath.Join(Dir(), "ended.lock")); !os.IsNotExist(err) {
+		t.Error("lock file of an ended session should be removed")
+	}
+}
+
+// increment bumps the prompt number under the session lock, the same
+// read-modify-write log-prompt performs.
+func increment(t *testing.T, sessionID string) {
+	t.Helper()
+	unlock, err := Lock(sessionID)
+	if err != nil {
+		t.Errorf("Lock: %v", err)
+		return
+	}
+	defer unlock()
+	sd, err := Read(sessionID)
+	if err != nil || sd == nil {
+		t.Errorf("Read: %+v, %v", sd, err)
+		return
+	}
+	sd.PromptNum++
+	Write(sessionID, sd)
+}
+
+func TestLock_ConcurrentGoroutines(t *testing.T) {
+	setHome(t)
+	Write("hammer", &SessionData{FilePath: "x"})
+
+	const workers, rounds = 16, 25
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				increment(t, "hammer")
+			}
+		}()
+	}
+	wg.Wait()
+
+	sd, _ := Read("hammer")
+	if sd == nil || sd.PromptNum != workers*rounds {
+		t.Fatalf("expected PromptNum %d, got %+v", workers*rounds, sd)
+	}
+}
+
+// TestLock_HelperProcess is run as a child by TestLock_ConcurrentProcesses.
+func TestLock_HelperProcess(t *testing.T) {
+	if os.Getenv("SESSION_LOCK_HELPER") != "1" {
+		t.Skip("helper process for TestLock_ConcurrentProcesses")
+	}
+	for j := 0; j < 25; j++ {
+		increment(t, "hammer")
+	}
+}
+
+func TestLock_ConcurrentProcesses(t *testing.T) {
+	home := setHome(t)
+	Write("hammer", &SessionData{FilePath: "x"})
+
+	const procs = 6
+	var cmds []*exec.Cmd
+	for i := 0; i < procs; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestLock_HelperProcess$")
+		cmd.Env = append(os.Environ(), "SESSION_LOCK_HELPER=1", "HOME="+home, "USERPROFILE="+home)
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds = append(cmds, cmd)
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("helper process failed: %v", err)
+		}
+	}
+
+	sd, _ := Read("hammer")
+	if sd == nil || sd.PromptNum != procs*25 {
+		t.Fatalf("expected PromptNum %d, got %+v", procs*25, sd)
+	}
+}
+
+func TestLock_ReleaseIsIdempotent(t *testing.T) {
+	setHome(t)
+	unlock, err := Lock("twice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	unlock()
+
+	unlock, err = Lock("twice")
+	if err != nil {
+		t.Fatalf("lock after release should succeed: %v", err)
+	}
+	unlock()
+}
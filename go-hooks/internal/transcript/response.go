@@ -0,0 +1,101 @@
+package transcript
+
+// SyntheticModel is the model name Claude Code records on messages it
+// generates locally (e.g. interruption notices); they carry no real usage.
+const SyntheticModel = "<synthetic>"
+
+// Response is the assistant output of one turn, as logged by the Stop hook.
+type Response struct {
+	// Texts holds every assistant text message of the turn, in order.
+	Texts []string
+	// Plan is the latest plan content recorded during the turn.
+	Plan string
+	// LastUUID is the uuid of the last main-chain entry read.
+	LastUUID string
+	// Model is the model of the last main-chain assistant message.
+	Model string
+	// Summary is the text of the last summary entry Claude Code wrote, if any.
+	Summary string
+	// CompactSummary is the conversation summary written by the last
+	// compaction, and CompactUUID the uuid of its entry.
+	CompactSummary string
+	CompactUUID    string
+	// Usage is the token usage of every assistant message read (including
+	// sub-agents), keyed by model.
+	Usage map[string]Usage
+}
+
+// CollectResponse reads all entries from r and returns the assistant output
+// written since the most recent user prompt. Entries up to and including
+// afterUUID were already logged and are skipped. Sub-agent (sidechain)
+// entries are ignored.
+func CollectResponse(r *Reader, afterUUID string) (Response, error) {
+	var resp Response
+	// Claude Code writes one line per content block, each repeating the
+	// message's usage, so usage is tracked per message id (last line wins).
+	type messageUsage struct {
+		model string
+		usage Usage
+	}
+	var byMessage map[string]messageUsage
+	var order []string
+	for r.Next() {
+		e := r.Entry()
+		if e.Type == TypeAssistant && e.Message != nil && e.Message.Usage != nil {
+			if byMessage == nil {
+				byMessage = make(map[string]messageUsage)
+			}
+			id := e.Message.ID
+			if id == "" {
+				id = e.UUID
+			}
+			if _, ok := byMessage[id]; !ok {
+				order = append(order, id)
+			}
+			byMessage[id] = messageUsage{model: e.Message.Model, usage: *e.Message.Usage}
+		}
+		if e.Type == TypeSummary && e.Summary != "" {
+			resp.Summary = e.Summary
+		}
+		if e.IsSidechain {
+			continue
+		}
+		if e.UUID != "" {
+			resp.LastUUID = e.UUID
+			if e.UUID == afterUUID {
+				resp.Texts, resp.Plan = nil, ""
+				resp.CompactSummary, resp.CompactUUID = "", ""
+				byMessage, order = nil, nil
+				continue
+			}
+		}
+		if e.IsCompactSummary {
+			resp.CompactSummary, resp.CompactUUID = e.Text(), e.UUID
+			continue
+		}
+		if e.IsPrompt() {
+			resp.Texts, resp.Plan = nil, ""
+		}
+		if e.PlanContent != "" {
+			resp.Plan = e.PlanContent
+		}
+		if e.Type == TypeAssistant {
+			if text := e.Text(); text != "" {
+				resp.Texts = append(resp.Texts, text)
+			}
+			if m := e.Message; m != nil && m.Model != "" && m.Model != SyntheticModel {
+				resp.Model = m.Model
+			}
+		}
+	}
+	for _, id := range order {
+		mu := byMessage[id]
+		if resp.Usage == nil {
+			resp.Usage = make(map[string]Usage)
+		}
+		total := resp.Usage[mu.model]
+		total.Add(mu.usage)
+		resp.Usage[mu.model] = total
+	}
+	return resp, r.Err()
+}
@@ -0,0 +1,140 @@
+package transcript
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const agenticRun = `{"type":"user","uuid":"p1","message":{"role":"user","content":"first prompt"}}
+{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"old answer"}]}}
+{"type":"user","uuid":"p2","planContent":"1. find bug\n2. fix it","message":{"role":"user","content":"fix the bug"}}
+{"type":"assistant","uuid":"a2","message":{"role":"assistant","content":[{"type":"text","text":"I found the bug in X"},{"type":"tool_use","id":"t1","name":"Edit","input":{}}]}}
+{"type":"user","uuid":"r1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}
+{"type":"user","uuid":"s1","isSidechain":true,"message":{"role":"user","content":"sub-agent task"}}
+{"type":"assistant","uuid":"s2","isSidechain":true,"message":{"role":"assistant","content":[{"type":"text","text":"sub-agent chatter"}]}}
+{"type":"assistant","uuid":"a3","message":{"role":"assistant","content":[{"type":"text","text":"now fixing Y"}]}}
+{"type":"assistant","uuid":"a4","message":{"role":"assistant","content":[{"type":"text","text":"Done."}]}}
+`
+
+// TestCollectResponse_AllTurnTexts verifies every assistant text since the last prompt is kept.
+func TestCollectResponse_AllTurnTexts(t *testing.T) {
+	resp, err := CollectResponse(NewReader(strings.NewReader(agenticRun)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"I found the bug in X", "now fixing Y", "Done."}
+	if !reflect.DeepEqual(resp.Texts, want) {
+		t.Errorf("Texts: got %q, want %q", resp.Texts, want)
+	}
+	if resp.Plan != "1. find bug\n2. fix it" {
+		t.Errorf("Plan: got %q", resp.Plan)
+	}
+	if resp.LastUUID != "a4" {
+		t.Errorf("LastUUID: got %q, want a4", resp.LastUUID)
+	}
+}
+
+// TestCollectResponse_AfterUUID verifies already-logged entries are skipped.
+func TestCollectResponse_AfterUUID(t *testing.T) {
+	resp, err := CollectResponse(NewReader(strings.NewReader(agenticRun)), "a3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Done."}; !reflect.DeepEqual(resp.Texts, want) {
+		t.Errorf("Texts: got %q, want %q", resp.Texts, want)
+	}
+	if resp.Plan != "" {
+		t.Errorf("Plan should already be logged, got %q", resp.Plan)
+	}
+}
+
+// TestCollectResponse_NothingNew verifies a repeated Stop logs nothing.
+func TestCollectResponse_NothingNew(t *testing.T) {
+	resp, err := CollectResponse(NewReader(strings.NewReader(agenticRun)), "a4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Texts) != 0 || resp.Plan != "" {
+		t.Errorf("expected empty response, got %+v", resp)
+	}
+}
+
+// TestCollectResponse_Summary verifies the latest summary entry is picked up.
+func TestCollectResponse_Summary(t *testing.T) {
+	run := `{"type":"summary","summary":"Old topic","leafUuid":"x"}
+{"type":"user","uuid":"p1","message":{"role":"user","content":"go"}}
+{"type":"summary","summary":"Fixing the login redirect","leafUuid":"p1"}
+{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"ok"}]}}
+`
+	resp, err := CollectResponse(NewReader(strings.NewReader(run)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Summary != "Fixing the login redirect" {
+		t.Errorf("Summary: got %q", resp.Summary)
+	}
+	if want := []string{"ok"}; !reflect.DeepEqual(resp.Texts, want) || resp.LastUUID != "a1" {
+		t.Errorf("summary entries should not disturb the turn, got %+v", resp)
+	}
+}
+
+// TestCollectResponse_CompactSummary verifies the compaction summary is
+// captured apart from the turn, and forgotten once logged.
+func TestCollectResponse_CompactSummary(t *testing.T) {
+	run := `{"type":"user","uuid":"p1","message":{"role":"user","content":"go"}}
+{"type":"user","uuid":"c1","isCompactSummary":true,"message":{"role":"user","content":"This session is being continued. We fixed X."}}
+{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"ok"}]}}
+`
+	resp, err := CollectResponse(NewReader(strings.NewReader(run)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.CompactSummary != "This session is being continued. We fixed X." || resp.CompactUUID != "c1" {
+		t.Errorf("compact summary: got %q (%q)", resp.CompactSummary, resp.CompactUUID)
+	}
+	if want := []string{"ok"}; !reflect.DeepEqual(resp.Texts, want) {
+		t.Errorf("compact summary should not disturb the turn, got %+v", resp.Texts)
+	}
+
+	resp, _ = CollectResponse(NewReader(strings.NewReader(run)), "c1")
+	if resp.CompactSummary != "" {
+		t.Errorf("summary before afterUUID was already logged, got %q", resp.CompactSummary)
+	}
+}
+
+const usageRun = `{"type":"user","uuid":"p1","message":{"role":"user","content":"go"}}
+{"type":"assistant","uuid":"a1","message":{"id":"msg_1","model":"claude-sonnet-4-5","role":"assistant","content":[{"type":"text","text":"step"}],"usage":{"input_tokens":100,"output_tokens":5,"cache_read_input_tokens":1000,"cache_creation_input_tokens":10}}}
+{"type":"assistant","uuid":"a2","message":{"id":"msg_1","model":"claude-sonnet-4-5","role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Task","input":{}}],"usage":{"input_tokens":100,"output_tokens":50,"cache_read_input_tokens":1000,"cache_creation_input_tokens":10}}}
+{"type":"assistant","uuid":"s1","isSidechain":true,"message":{"id":"msg_2","model":"claude-haiku-4-5","role":"assistant","content":[{"type":"text","text":"sub"}],"usage":{"input_tokens":7,"output_tokens":3}}}
+{"type":"assistant","uuid":"a3","message":{"id":"msg_3","model":"<synthetic>","role":"assistant","content":[{"type":"text","text":"interrupted"}]}}
+`
+
+// TestCollectResponse_Usage verifies usage is de-duplicated per message and grouped by model.
+func TestCollectResponse_Usage(t *testing.T) {
+	resp, err := CollectResponse(NewReader(strings.NewReader(usageRun)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Usage{
+		"claude-sonnet-4-5": {InputTokens: 100, OutputTokens: 50, CacheReadInputTokens: 1000, CacheCreationInputTokens: 10},
+		"claude-haiku-4-5":  {InputTokens: 7, OutputTokens: 3},
+	}
+	if !reflect.DeepEqual(resp.Usage, want) {
+		t.Errorf("Usage: got %+v, want %+v", resp.Usage, want)
+	}
+	if resp.Model != "claude-sonnet-4-5" {
+		t.Errorf("Model: got %q, want claude-sonnet-4-5 (synthetic ignored)", resp.Model)
+	}
+}
+
+// TestCollectResponse_UsageAfterUUID verifies usage already logged is not counted again.
+func TestCollectResponse_UsageAfterUUID(t *testing.T) {
+	resp, err := CollectResponse(NewReader(strings.NewReader(usageRun)), "a2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp.Usage["claude-sonnet-4-5"]; ok {
+		t.Errorf("usage before afterUUID should be dropped, got %+v", resp.Usage)
+	}
+}
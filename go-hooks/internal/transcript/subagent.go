@@ -0,0 +1,88 @@
+package transcript
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// taskTools are the names of the tool that launches sub-agents.
+var taskTools = map[string]bool{"Task": true, "Agent": true}
+
+// SubagentRun is one sub-agent run, reconstructed from sidechain entries
+// linked through parentUuid.
+type SubagentRun struct {
+	// RootUUID is the uuid of the prompt that started the run.
+	RootUUID string
+	Prompt   string
+	// Result is the text of the run's last assistant message.
+	Result string
+	// Description and Type come from the Task call that launched the run.
+	Description string
+	Type        string
+	// Done is set once the last assistant message made no further tool calls.
+	Done       bool
+	Start, End time.Time
+}
+
+// TaskCall is a main-chain call of the Task tool.
+type TaskCall struct {
+	Description  string `json:"description"`
+	Prompt       string `json:"prompt"`
+	SubagentType string `json:"subagent_type"`
+}
+
+// CollectSubagents reads all entries from r and returns the sub-agent runs
+// that start in it, in order, and the Task calls of the main chain. Entries
+// of runs that started before r are ignored.
+func CollectSubagents(r *Reader) ([]*SubagentRun, []TaskCall, error) {
+	var runs []*SubagentRun
+	var calls []TaskCall
+	runOf := make(map[string]*SubagentRun) // entry uuid -> its run
+	for r.Next() {
+		e := r.Entry()
+		if !e.IsSidechain {
+			for _, b := range e.Blocks(BlockToolUse) {
+				var c TaskCall
+				if taskTools[b.Name] && json.Unmarshal(b.Input, &c) == nil && c.Prompt != "" {
+					calls = append(calls, c)
+				}
+			}
+			continue
+		}
+
+		run := runOf[e.ParentUUID]
+		if run == nil {
+			if e.Type != TypeUser || len(e.Blocks(BlockToolResult)) > 0 || e.Text() == "" {
+				continue // part of a run that started earlier
+			}
+			run = &SubagentRun{RootUUID: e.UUID, Prompt: e.Text(), Start: e.Timestamp}
+			runs = append(runs, run)
+		}
+		if e.UUID != "" {
+			runOf[e.UUID] = run
+		}
+		if !e.Timestamp.IsZero() {
+			run.End = e.Timestamp
+		}
+		if e.Type == TypeAssistant {
+			if text := e.Text(); text != "" {
+				run.Result = text
+			}
+			run.Done = len(e.Blocks(BlockToolUse)) == 0
+		}
+	}
+	return runs, calls, r.Err()
+}
+
+// Describe fills in the description and type of each run from the Task
+// call whose prompt started it.
+func Describe(runs []*SubagentRun, calls []TaskCall) {
+	for _, run := range runs {
+		for _, c := range calls {
+			if c.Prompt == run.Prompt {
+				run.Description, run.Type = c.Description, c.SubagentType
+				break
+			}
+		}
+	}
+}
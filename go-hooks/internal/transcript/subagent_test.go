@@ -0,0 +1,42 @@
+package transcript
+
+import (
+	"strings"
+	"testing"
+)
+
+const subagentRun = `{"type":"user","uuid":"u1","message":{"role":"user","content":"research it"}}
+{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Task","input":{"description":"Find auth code","prompt":"Find where auth happens","subagent_type":"Explore"}}]}}
+{"type":"assistant","uuid":"old","parentUuid":"before","isSidechain":true,"message":{"role":"assistant","content":[{"type":"text","text":"tail of an earlier run"}]}}
+{"type":"user","uuid":"s1","isSidechain":true,"message":{"role":"user","content":"Find where auth happens"}}
+{"type":"user","uuid":"x1","isSidechain":true,"message":{"role":"user","content":"Parallel agent"}}
+{"type":"assistant","uuid":"s2","parentUuid":"s1","isSidechain":true,"message":{"role":"assistant","content":[{"type":"text","text":"Looking."}]}}
+{"type":"assistant","uuid":"s3","parentUuid":"s2","isSidechain":true,"message":{"role":"assistant","content":[{"type":"tool_use","id":"g1","name":"Grep","input":{}}]}}
+{"type":"user","uuid":"s4","parentUuid":"s3","isSidechain":true,"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"g1","content":"auth.go"}]}}
+{"type":"assistant","uuid":"s5","parentUuid":"s4","isSidechain":true,"message":{"role":"assistant","content":[{"type":"text","text":"Auth is in auth.go."}]}}
+{"type":"assistant","uuid":"x2","parentUuid":"x1","isSidechain":true,"message":{"role":"assistant","content":[{"type":"tool_use","id":"g2","name":"Read","input":{}}]}}
+`
+
+// TestCollectSubagents verifies interleaved sidechains are split into runs
+// by parentUuid, and runs still calling tools are not done.
+func TestCollectSubagents(t *testing.T) {
+	runs, calls, err := CollectSubagents(NewReader(strings.NewReader(subagentRun)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || len(calls) != 1 {
+		t.Fatalf("expected 2 runs and 1 Task call, got %d and %d", len(runs), len(calls))
+	}
+	Describe(runs, calls)
+
+	got := runs[0]
+	if got.RootUUID != "s1" || got.Prompt != "Find where auth happens" || got.Result != "Auth is in auth.go." || !got.Done {
+		t.Errorf("unexpected first run: %+v", got)
+	}
+	if got.Description != "Find auth code" || got.Type != "Explore" {
+		t.Errorf("expected description from the Task call, got %q / %q", got.Description, got.Type)
+	}
+	if runs[1].RootUUID != "x1" || runs[1].Done || runs[1].Description != "" {
+		t.Errorf("parallel run should be pending and undescribed: %+v", runs[1])
+	}
+}
@@ -0,0 +1,246 @@
+// Package transcript parses Claude Code session transcripts (JSONL files under
+// ~/.claude/projects). Each line is one Entry; Reader streams them in order.
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Entry types.
+const (
+	TypeUser      = "user"
+	TypeAssistant = "assistant"
+	TypeSummary   = "summary"
+	TypeSystem    = "system"
+)
+
+// Content block types.
+const (
+	BlockText       = "text"
+	BlockThinking   = "thinking"
+	BlockToolUse    = "tool_use"
+	BlockToolResult = "tool_result"
+)
+
+// Entry is one line of a transcript.
+type Entry struct {
+	Type             string    `json:"type"`
+	Subtype          string    `json:"subtype"`
+	UUID             string    `json:"uuid"`
+	ParentUUID       string    `json:"parentUuid"`
+	SessionID        string    `json:"sessionId"`
+	Timestamp        time.Time `json:"timestamp"`
+	Cwd              string    `json:"cwd"`
+	GitBranch        string    `json:"gitBranch"`
+	IsSidechain      bool      `json:"isSidechain"`
+	IsMeta           bool      `json:"isMeta"`
+	IsCompactSummary bool      `json:"isCompactSummary"`
+	PlanContent      string    `json:"planContent"`
+	Message          *Message  `json:"message"`
+
+	// Summary entries (Type == TypeSummary) carry these instead of a message.
+	Summary  string `json:"summary"`
+	LeafUUID string `json:"leafUuid"`
+}
+
+// Message is the API message wrapped by user and assistant entries.
+type Message struct {
+	ID      string  `json:"id"`
+	Role    string  `json:"role"`
+	Model   string  `json:"model"`
+	Content Content `json:"content"`
+	Usage   *Usage  `json:"usage"`
+}
+
+// Usage is the token accounting attached to assistant messages.
+type Usage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+// Add adds o to u.
+func (u *Usage) Add(o Usage) {
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.CacheCreationInputTokens += o.CacheCreationInputTokens
+	u.CacheReadInputTokens += o.CacheReadInputTokens
+}
+
+// Block is a single content block of a message.
+type Block struct {
+	Type string `json:"type"`
+
+	// text
+	Text string `json:"text"`
+
+	// thinking
+	Thinking string `json:"thinking"`
+
+	// tool_use
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+
+	// tool_result
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+	IsError   bool            `json:"is_error"`
+}
+
+// Content is a message's content. The transcript stores it either as a plain
+// string (typed user prompts) or as an array of blocks; both decode to blocks.
+type Content []Block
+
+// UnmarshalJSON accepts both the string and the array form.
+func (c *Content) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*c = Content{{Type: BlockText, Text: s}}
+		return nil
+	}
+	var blocks []Block
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return err
+	}
+	*c = blocks
+	return nil
+}
+
+// Blocks returns the message's blocks of the given type.
+func (e *Entry) Blocks(blockType string) []Block {
+	if e.Message == nil {
+		return nil
+	}
+	var out []Block
+	for _, b := range e.Message.Content {
+		if b.Type == blockType {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// Text returns the non-empty text blocks of the message joined by blank lines.
+func (e *Entry) Text() string {
+	var texts []string
+	for _, b := range e.Blocks(BlockText) {
+		if strings.TrimSpace(b.Text) != "" {
+			texts = append(texts, b.Text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// IsPrompt reports whether the entry is a prompt typed by the user, as opposed
+// to tool results, injected meta messages, compaction summaries or sub-agent
+// traffic, which are also recorded with type "user".
+func (e *Entry) IsPrompt() bool {
+	if e.Type != TypeUser || e.IsMeta || e.IsSidechain || e.IsCompactSummary {
+		return false
+	}
+	if len(e.Blocks(BlockToolResult)) > 0 {
+		return false
+	}
+	return e.Text() != ""
+}
+
+// Reader streams entries from a transcript. Blank and malformed lines are
+// skipped; lines may be arbitrarily long.
+type Reader struct {
+	r      *bufio.Reader
+	entry  Entry
+	err    error
+	offset int64
+}
+
+// NewReader returns a Reader reading JSONL from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// Next advances to the next entry. It returns false at end of input or on a
+// read error, which Err reports.
+func (r *Reader) Next() bool {
+	for r.err == nil {
+		line, err := r.r.ReadBytes('\n')
+		if err != nil {
+			r.err = err
+		} else {
+			// Only newline-terminated lines count as consumed: a trailing
+			// partial line may still be being written by Claude Code.
+			r.offset += int64(len(line))
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		r.entry = Entry{}
+		if json.Unmarshal(line, &r.entry) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Entry returns the entry read by the last successful call to Next.
+// It is overwritten by the following call.
+func (r *Reader) Entry() *Entry {
+	return &r.entry
+}
+
+// Offset returns the byte offset just past the last complete line read,
+// suitable for resuming with Open later.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Err returns the first read error, or nil at a clean end of input.
+func (r *Reader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// File is a Reader over a transcript file opened with Open.
+type File struct {
+	*Reader
+	f *os.File
+}
+
+// Open opens the transcript at path and positions it at offset, so that only
+// lines appended since an earlier read are returned. If the file is shorter
+// than offset (it was replaced or truncated), reading starts from the top.
+func Open(path string, offset int64) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err != nil || offset < 0 || offset > info.Size() {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	r := NewReader(f)
+	r.offset = offset
+	return &File{Reader: r, f: f}, nil
+}
+
+// Close closes the underlying file.
+func (f *File) Close() error {
+	return f.f.Close()
+}
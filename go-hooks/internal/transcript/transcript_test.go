@@ -0,0 +1,187 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `{"type":"summary","summary":"Fix login bug","leafUuid":"u4"}
+{"type":"user","uuid":"u1","parentUuid":null,"sessionId":"s1","timestamp":"2026-02-13T13:50:22.000Z","cwd":"/work","gitBranch":"main","message":{"role":"user","content":"fix the login bug"}}
+{"type":"assistant","uuid":"u2","parentUuid":"u1","timestamp":"2026-02-13T13:50:25.000Z","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"Looking at auth.go"},{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"auth.go"}}],"usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":30,"cache_read_input_tokens":40}}}
+
+not json at all
+{"type":"user","uuid":"u3","parentUuid":"u2","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"package auth"}]}}
+{"type":"assistant","uuid":"u4","parentUuid":"u3","message":{"id":"msg_2","role":"assistant","content":[{"type":"text","text":"Fixed."}]}}
+`
+
+func readAll(t *testing.T, input string) []Entry {
+	t.Helper()
+	r := NewReader(strings.NewReader(input))
+	var entries []Entry
+	for r.Next() {
+		entries = append(entries, *r.Entry())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return entries
+}
+
+// TestReader_SkipsBlankAndMalformed verifies only valid lines become entries.
+func TestReader_SkipsBlankAndMalformed(t *testing.T) {
+	entries := readAll(t, sample)
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(entries))
+	}
+	wantTypes := []string{TypeSummary, TypeUser, TypeAssistant, TypeUser, TypeAssistant}
+	for i, want := range wantTypes {
+		if entries[i].Type != want {
+			t.Errorf("entry %d: type %q, want %q", i, entries[i].Type, want)
+		}
+	}
+}
+
+// TestReader_Fields verifies metadata, usage and blocks are decoded.
+func TestReader_Fields(t *testing.T) {
+	entries := readAll(t, sample)
+
+	if entries[0].Summary != "Fix login bug" || entries[0].LeafUUID != "u4" {
+		t.Errorf("summary entry mismatch: %+v", entries[0])
+	}
+
+	user := entries[1]
+	wantTS := time.Date(2026, 2, 13, 13, 50, 22, 0, time.UTC)
+	if user.UUID != "u1" || user.SessionID != "s1" || user.Cwd != "/work" || user.GitBranch != "main" || !user.Timestamp.Equal(wantTS) {
+		t.Errorf("user entry metadata mismatch: %+v", user)
+	}
+
+	asst := entries[2]
+	if asst.ParentUUID != "u1" || asst.Message.Model != "claude-sonnet-4-5" || asst.Message.ID != "msg_1" {
+		t.Errorf("assistant entry metadata mismatch: %+v", asst)
+	}
+	u := asst.Message.Usage
+	if u == nil || u.InputTokens != 10 || u.OutputTokens != 20 || u.CacheCreationInputTokens != 30 || u.CacheReadInputTokens != 40 {
+		t.Errorf("usage mismatch: %+v", u)
+	}
+	if got := asst.Blocks(BlockThinking); len(got) != 1 || got[0].Thinking != "hmm" {
+		t.Errorf("thinking blocks mismatch: %+v", got)
+	}
+	if got := asst.Blocks(BlockToolUse); len(got) != 1 || got[0].Name != "Read" || got[0].ID != "t1" {
+		t.Errorf("tool_use blocks mismatch: %+v", got)
+	}
+	if got := entries[3].Blocks(BlockToolResult); len(got) != 1 || got[0].ToolUseID != "t1" {
+		t.Errorf("tool_result blocks mismatch: %+v", got)
+	}
+}
+
+// TestEntry_Text verifies string content and text blocks are both exposed.
+func TestEntry_Text(t *testing.T) {
+	entries := readAll(t, sample)
+	if got := entries[1].Text(); got != "fix the login bug" {
+		t.Errorf("user text: got %q", got)
+	}
+	if got := entries[2].Text(); got != "Looking at auth.go" {
+		t.Errorf("assistant text: got %q", got)
+	}
+	if got := entries[3].Text(); got != "" {
+		t.Errorf("tool_result text: got %q, want empty", got)
+	}
+}
+
+// TestEntry_IsPrompt verifies only typed prompts count as prompts.
+func TestEntry_IsPrompt(t *testing.T) {
+	entries := readAll(t, sample)
+	for i, want := range []bool{false, true, false, false, false} {
+		if got := entries[i].IsPrompt(); got != want {
+			t.Errorf("entry %d: IsPrompt=%v, want %v", i, got, want)
+		}
+	}
+
+	meta := Entry{Type: TypeUser, IsMeta: true, Message: &Message{Content: Content{{Type: BlockText, Text: "caveat"}}}}
+	if meta.IsPrompt() {
+		t.Error("meta message should not be a prompt")
+	}
+	side := Entry{Type: TypeUser, IsSidechain: true, Message: &Message{Content: Content{{Type: BlockText, Text: "task"}}}}
+	if side.IsPrompt() {
+		t.Error("sidechain message should not be a prompt")
+	}
+}
+
+// TestReader_LongLine verifies lines far beyond bufio.Scanner's default limit parse.
+func TestReader_LongLine(t *testing.T) {
+	long := strings.Repeat("x", 3*1024*1024)
+	input := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"` + long + `"}]}}` + "\n"
+	entries := readAll(t, input)
+	if len(entries) != 1 || len(entries[0].Text()) != len(long) {
+		t.Fatalf("long line not parsed")
+	}
+}
+
+// TestReader_NoTrailingNewline verifies the final line is read without a newline.
+func TestReader_NoTrailingNewline(t *testing.T) {
+	entries := readAll(t, `{"type":"user","uuid":"a"}`)
+	if len(entries) != 1 || entries[0].UUID != "a" {
+		t.Fatalf("expected final unterminated line to parse, got %+v", entries)
+	}
+}
+
+// TestReader_OffsetSkipsPartialLine verifies an unterminated line is not counted as consumed.
+func TestReader_OffsetSkipsPartialLine(t *testing.T) {
+	first := `{"type":"user","uuid":"a"}` + "\n"
+	r := NewReader(strings.NewReader(first + `{"type":"user","uuid":"b"}`))
+	for r.Next() {
+	}
+	if got := r.Offset(); got != int64(len(first)) {
+		t.Errorf("Offset: got %d, want %d", got, len(first))
+	}
+}
+
+// TestOpen_ResumesAtOffset verifies a second read returns only appended lines.
+func TestOpen_ResumesAtOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	os.WriteFile(path, []byte(`{"type":"user","uuid":"a"}`+"\n"+`{"type":"user","uuid":"b"}`+"\n"), 0644)
+
+	f, err := Open(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for f.Next() {
+	}
+	offset := f.Offset()
+	f.Close()
+
+	af, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	af.WriteString(`{"type":"user","uuid":"c"}` + "\n")
+	af.Close()
+
+	f, err = Open(path, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var uuids []string
+	for f.Next() {
+		uuids = append(uuids, f.Entry().UUID)
+	}
+	if len(uuids) != 1 || uuids[0] != "c" {
+		t.Errorf("expected only [c] after offset, got %v", uuids)
+	}
+}
+
+// TestOpen_OffsetBeyondEOF verifies a shrunken transcript is re-read from the start.
+func TestOpen_OffsetBeyondEOF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	os.WriteFile(path, []byte(`{"type":"user","uuid":"a"}`+"\n"), 0644)
+
+	f, err := Open(path, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if !f.Next() || f.Entry().UUID != "a" {
+		t.Error("expected to read from the start when offset exceeds file size")
+	}
+}
@@ -107,6 +107,20 @@ $hooksConfig = @{
             )
         }
     )
+    "SessionStart" = @(
+        @{
+            hooks = @(
+                @{ type = "command"; command = "$obsidianExe session-start" }
+            )
+        }
+    )
+    "SessionEnd" = @(
+        @{
+            hooks = @(
+                @{ type = "command"; command = "$obsidianExe session-end" }
+            )
+        }
+    )
     "UserPromptSubmit" = @(
         @{
             hooks = @(
@@ -114,11 +128,36 @@ $hooksConfig = @{
             )
         }
     )
+    "PostToolUse" = @(
+        @{
+            matcher = "*"
+            hooks = @(
+                @{ type = "command"; command = "$obsidianExe log-tool" }
+            )
+        }
+    )
+    "SubagentStop" = @(
+        @{
+            matcher = "*"
+            hooks = @(
+                @{ type = "command"; command = "$obsidianExe log-subagent" }
+            )
+        }
+    )
+    "PreCompact" = @(
+        @{
+            matcher = "*"
+            hooks = @(
+                @{ type = "command"; command = "$obsidianExe log-compact" }
+            )
+        }
+    )
     "Notification" = @(
         @{
             matcher = "*"
             hooks = @(
-                @{ type = "command"; command = "$notifyExe --message `"Needs your attention!`"" }
+                @{ type = "command"; command = "$notifyExe --stdin --message `"Needs your attention!`"" }
+                @{ type = "command"; command = "$obsidianExe log-notification" }
             )
         }
     )